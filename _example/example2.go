@@ -30,7 +30,7 @@ func two() {
 	check(err)
 	defer vst2plugin.Close()
 	vst2processor := vst2.NewProcessor(
-		vst2plugin,
+		vst2.NewPlugin(vst2plugin),
 		bufferSize,
 		wavPump.WavSampleRate(),
 		wavPump.WavNumChannels(),
@@ -58,7 +58,7 @@ func five() {
 	defer vst2plugin.Close()
 
 	vst2processor := vst2.NewProcessor(
-		vst2plugin,
+		vst2.NewPlugin(vst2plugin),
 		bs,
 		wavPump1.WavSampleRate(),
 		wavPump1.WavNumChannels(),
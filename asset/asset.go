@@ -1,6 +1,7 @@
 package asset
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/dudk/phono"
@@ -8,6 +9,7 @@ import (
 
 // Asset is a sink which uses a regular buffer as underlying storage.
 // It can be used as processing input and always should be copied.
+// To stream asset into pipe, use Pump.
 type Asset struct {
 	phono.UID
 	phono.Buffer
@@ -34,3 +36,64 @@ func (a *Asset) Sink(string) (phono.SinkFunc, error) {
 		return nil
 	}, nil
 }
+
+// Pump streams asset into pipe with buffers of defined size. Pump can
+// be used in many runs, every run starts from the beginning of asset
+// unless SetPosition is called before it.
+type Pump struct {
+	phono.UID
+	asset      *Asset
+	bufferSize phono.BufferSize
+
+	m        sync.Mutex
+	position int64
+	moved    bool // position is set by SetPosition and no buffers are sent since.
+}
+
+// NewPump creates pump of asset. Number of channels is defined by asset.
+func NewPump(a *Asset, bufferSize phono.BufferSize) *Pump {
+	return &Pump{
+		UID:        phono.NewUID(),
+		asset:      a,
+		bufferSize: bufferSize,
+	}
+}
+
+// Reset implements pipe.Resetter. Pump starts from the beginning of asset
+// or from position set before run.
+func (p *Pump) Reset(string) error {
+	p.m.Lock()
+	if !p.moved {
+		p.position = 0
+	}
+	p.m.Unlock()
+	return nil
+}
+
+// SetPosition moves pump to position in samples, so the next buffer starts there.
+// Position must be within asset. It's safe to call SetPosition while pump is running.
+func (p *Pump) SetPosition(position int64) error {
+	if size := int64(p.asset.Buffer.Size()); position < 0 || position > size {
+		return fmt.Errorf("Position %v is out of range of %v samples", position, size)
+	}
+	p.m.Lock()
+	p.position = position
+	p.moved = true
+	p.m.Unlock()
+	return nil
+}
+
+// Pump returns copies of asset's buffers. The last buffer can be shorter.
+func (p *Pump) Pump(string) (phono.PumpFunc, error) {
+	return func() (phono.Buffer, error) {
+		p.m.Lock()
+		defer p.m.Unlock()
+		p.moved = false
+		b := p.asset.Buffer.Slice(p.position, int(p.bufferSize))
+		if b == nil {
+			return nil, phono.ErrEOP
+		}
+		p.position += int64(b.Size())
+		return b, nil
+	}, nil
+}
@@ -86,3 +86,55 @@ func TestPipe(t *testing.T) {
 		// assert.Nil(t, err)
 	}
 }
+
+func TestPump(t *testing.T) {
+	a := asset.New()
+	a.Buffer = phono.EmptyBuffer(2, 25)
+	pump := asset.NewPump(a, bufferSize)
+	sink := &mock.Sink{UID: phono.NewUID()}
+	p, err := pipe.New(
+		phono.SampleRate(44100),
+		pipe.WithPump(pump),
+		pipe.WithSinks(sink),
+	)
+	assert.Nil(t, err)
+	// asset can be pumped many times.
+	for i := 0; i < 2; i++ {
+		err = pipe.Wait(p.Run())
+		assert.Nil(t, err)
+		messageCount, samplesCount := sink.Count()
+		assert.Equal(t, int64(3), messageCount)
+		assert.Equal(t, int64(25), samplesCount)
+	}
+}
+
+func TestPumpSeek(t *testing.T) {
+	a := asset.New()
+	a.Buffer = phono.Buffer{[]float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}}
+	pump := asset.NewPump(a, bufferSize)
+	assert.NotNil(t, pump.SetPosition(-1))
+	assert.NotNil(t, pump.SetPosition(13))
+	sink := asset.New()
+	p, err := pipe.New(
+		phono.SampleRate(44100),
+		pipe.WithPump(pump),
+		pipe.WithSinks(sink),
+	)
+	assert.Nil(t, err)
+	// position is kept when run is started.
+	assert.Nil(t, pump.SetPosition(5))
+	assert.Nil(t, pipe.Wait(p.Run()))
+	assert.Equal(t, phono.Buffer{[]float64{5, 6, 7, 8, 9, 10, 11}}, sink.Buffer)
+
+	// seek within run.
+	fn, err := pump.Pump("")
+	assert.Nil(t, err)
+	assert.Nil(t, pump.Reset(""))
+	b, err := fn()
+	assert.Nil(t, err)
+	assert.Equal(t, phono.Buffer{[]float64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}}, b)
+	assert.Nil(t, pump.SetPosition(1))
+	b, err = fn()
+	assert.Nil(t, err)
+	assert.Equal(t, phono.Buffer{[]float64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}}, b)
+}
@@ -0,0 +1,141 @@
+// Package channel provides processors which change number and order of channels.
+package channel
+
+import (
+	"math"
+	"sync"
+
+	"github.com/dudk/phono"
+)
+
+// Mode defines how channels are summed into mono.
+type Mode int
+
+const (
+	// Average sums channels and divides result by number of channels.
+	Average Mode = iota
+	// Headroom sums channels with -3dB attenuation of every channel.
+	Headroom
+)
+
+// ToMono folds all channels into a single one.
+type ToMono struct {
+	phono.UID
+	mode Mode
+}
+
+// ToStereo duplicates single channel into two. Buffers with
+// more than one channel are passed through unchanged.
+type ToStereo struct {
+	phono.UID
+}
+
+// Flip inverts polarity of selected channels and swaps left and right
+// channels. Polarity is inverted before swap, so channel indices refer to
+// input channels. Channels which don't exist in buffer are ignored.
+// Buffers are processed in place.
+type Flip struct {
+	phono.UID
+
+	m      sync.Mutex
+	invert map[int]bool
+	swap   bool
+}
+
+// NewToMono creates new processor which converts buffers to mono.
+func NewToMono(mode Mode) *ToMono {
+	return &ToMono{
+		UID:  phono.NewUID(),
+		mode: mode,
+	}
+}
+
+// NewToStereo creates new processor which converts mono buffers to stereo.
+func NewToStereo() *ToStereo {
+	return &ToStereo{
+		UID: phono.NewUID(),
+	}
+}
+
+// Process implements phono.Processor.
+func (m *ToMono) Process(string) (phono.ProcessFunc, error) {
+	return func(b phono.Buffer) (phono.Buffer, error) {
+		if b.NumChannels() <= 1 {
+			return b, nil
+		}
+		gain := 1 / float64(b.NumChannels())
+		if m.mode == Headroom {
+			gain = 1 / math.Sqrt2
+		}
+		result := phono.EmptyBuffer(1, b.Size())
+		for i := range b {
+			for j, v := range b[i] {
+				result[0][j] += v * gain
+			}
+		}
+		return result, nil
+	}, nil
+}
+
+// Process implements phono.Processor.
+func (s *ToStereo) Process(string) (phono.ProcessFunc, error) {
+	return func(b phono.Buffer) (phono.Buffer, error) {
+		if b.NumChannels() != 1 {
+			return b, nil
+		}
+		result := phono.Buffer(make([][]float64, 2))
+		for i := range result {
+			result[i] = make([]float64, len(b[0]))
+			copy(result[i], b[0])
+		}
+		return result, nil
+	}, nil
+}
+
+// NewFlip creates new processor which doesn't change signal until it's configured.
+func NewFlip() *Flip {
+	return &Flip{
+		UID:    phono.NewUID(),
+		invert: make(map[int]bool),
+	}
+}
+
+// SetInvert enables or disables polarity inversion of channel. It's safe to
+// call while processing.
+func (f *Flip) SetInvert(channel int, invert bool) {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if invert {
+		f.invert[channel] = true
+	} else {
+		delete(f.invert, channel)
+	}
+}
+
+// SetSwap enables or disables swap of the first two channels. It's safe to
+// call while processing.
+func (f *Flip) SetSwap(swap bool) {
+	f.m.Lock()
+	f.swap = swap
+	f.m.Unlock()
+}
+
+// Process implements phono.Processor.
+func (f *Flip) Process(string) (phono.ProcessFunc, error) {
+	return func(b phono.Buffer) (phono.Buffer, error) {
+		f.m.Lock()
+		defer f.m.Unlock()
+		for i := range f.invert {
+			if i < 0 || i >= len(b) {
+				continue
+			}
+			for j := range b[i] {
+				b[i][j] = -b[i][j]
+			}
+		}
+		if f.swap && len(b) >= 2 {
+			b[0], b[1] = b[1], b[0]
+		}
+		return b, nil
+	}, nil
+}
@@ -0,0 +1,67 @@
+package channel_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/channel"
+)
+
+func TestToMono(t *testing.T) {
+	tests := []struct {
+		mode     channel.Mode
+		expected float64
+	}{
+		{mode: channel.Average, expected: 0.5},
+		{mode: channel.Headroom, expected: 1 / math.Sqrt2},
+	}
+	for _, test := range tests {
+		fn, err := channel.NewToMono(test.mode).Process("")
+		assert.Nil(t, err)
+		result, err := fn(phono.Buffer{[]float64{1, 0}, []float64{0, 1}})
+		assert.Nil(t, err)
+		assert.Equal(t, phono.NumChannels(1), result.NumChannels())
+		for _, v := range result[0] {
+			assert.InDelta(t, test.expected, v, 1e-9)
+		}
+	}
+}
+
+func TestToStereo(t *testing.T) {
+	fn, err := channel.NewToStereo().Process("")
+	assert.Nil(t, err)
+	in := phono.Buffer{[]float64{0.1, 0.2}}
+	result, err := fn(in)
+	assert.Nil(t, err)
+	assert.Equal(t, phono.Buffer{[]float64{0.1, 0.2}, []float64{0.1, 0.2}}, result)
+}
+
+func TestFlip(t *testing.T) {
+	f := channel.NewFlip()
+	fn, err := f.Process("")
+	assert.Nil(t, err)
+	result, err := fn(phono.Buffer{[]float64{0.1}, []float64{0.2}})
+	assert.Nil(t, err)
+	assert.Equal(t, phono.Buffer{[]float64{0.1}, []float64{0.2}}, result)
+
+	f.SetInvert(1, true)
+	f.SetInvert(5, true)
+	f.SetSwap(true)
+	result, err = fn(phono.Buffer{[]float64{0.1}, []float64{0.2}, []float64{0.3}})
+	assert.Nil(t, err)
+	assert.Equal(t, phono.Buffer{[]float64{-0.2}, []float64{0.1}, []float64{0.3}}, result)
+
+	// mono buffer isn't swapped.
+	result, err = fn(phono.Buffer{[]float64{0.1}})
+	assert.Nil(t, err)
+	assert.Equal(t, phono.Buffer{[]float64{0.1}}, result)
+
+	f.SetInvert(1, false)
+	f.SetSwap(false)
+	result, err = fn(phono.Buffer{[]float64{0.1}, []float64{0.2}})
+	assert.Nil(t, err)
+	assert.Equal(t, phono.Buffer{[]float64{0.1}, []float64{0.2}}, result)
+}
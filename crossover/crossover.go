@@ -0,0 +1,162 @@
+// Package crossover provides processors which split signal into frequency bands.
+package crossover
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/dudk/phono"
+)
+
+// Crossover splits signal into bands with 4th order Linkwitz-Riley filters.
+// N cutoff frequencies define N+1 bands. Every band is a processor, so
+// signal is copied to bands with tee, every band is processed separately
+// and results are summed up with mixer. Lower bands are compensated with
+// allpass filters, so the sum of all bands has flat frequency response.
+type Crossover struct {
+	sampleRate phono.SampleRate
+	cutoffs    []float64
+}
+
+// New creates crossover. Cutoff frequencies are in Hz, they must be in
+// ascending order and below Nyquist frequency.
+func New(sampleRate phono.SampleRate, cutoffs ...float64) (*Crossover, error) {
+	if len(cutoffs) == 0 {
+		return nil, fmt.Errorf("Crossover needs at least one cutoff frequency")
+	}
+	for i, f := range cutoffs {
+		if f <= 0 || f >= float64(sampleRate)/2 {
+			return nil, fmt.Errorf("Cutoff frequency %v is out of range for sample rate %v", f, sampleRate)
+		}
+		if i > 0 && f <= cutoffs[i-1] {
+			return nil, fmt.Errorf("Cutoff frequency %v must be above %v", f, cutoffs[i-1])
+		}
+	}
+	return &Crossover{
+		sampleRate: sampleRate,
+		cutoffs:    append([]float64(nil), cutoffs...),
+	}, nil
+}
+
+// NumBands returns number of bands.
+func (c *Crossover) NumBands() int {
+	return len(c.cutoffs) + 1
+}
+
+// Bands returns new processors of all bands from the lowest to the highest.
+func (c *Crossover) Bands() []*Band {
+	bands := make([]*Band, c.NumBands())
+	for i := range bands {
+		bands[i] = c.band(i)
+	}
+	return bands
+}
+
+// band creates processor of band with index.
+func (c *Crossover) band(index int) *Band {
+	b := &Band{
+		UID: phono.NewUID(),
+	}
+	// band is a high part of every lower split.
+	for i := 0; i < index; i++ {
+		hp := c.coefficients(highpass, c.cutoffs[i])
+		b.filters = append(b.filters, hp, hp)
+	}
+	if index < len(c.cutoffs) {
+		lp := c.coefficients(lowpass, c.cutoffs[index])
+		b.filters = append(b.filters, lp, lp)
+	}
+	// the sum of low and high pass filters of higher cutoffs is allpass.
+	for i := index + 1; i < len(c.cutoffs); i++ {
+		b.filters = append(b.filters, c.coefficients(allpass, c.cutoffs[i]))
+	}
+	return b
+}
+
+// Band is a processor which passes one band of crossover. Filter state is
+// kept per channel between buffers and cleared at the start of every run.
+// Buffers are processed in place.
+type Band struct {
+	phono.UID
+	filters []biquad
+	state   [][]biquadState // state of every filter per channel.
+}
+
+// Process implements phono.Processor.
+func (b *Band) Process(string) (phono.ProcessFunc, error) {
+	return func(buf phono.Buffer) (phono.Buffer, error) {
+		if len(b.state) != len(buf) {
+			b.state = make([][]biquadState, len(buf))
+			for i := range b.state {
+				b.state[i] = make([]biquadState, len(b.filters))
+			}
+		}
+		for i := range buf {
+			for k := range b.filters {
+				b.filters[k].process(buf[i], &b.state[i][k])
+			}
+		}
+		return buf, nil
+	}, nil
+}
+
+// Reset implements pipe.Resetter.
+func (b *Band) Reset(string) error {
+	b.state = nil
+	return nil
+}
+
+// filterType is a type of biquad filter.
+type filterType int
+
+const (
+	lowpass filterType = iota
+	highpass
+	allpass
+)
+
+// biquad is a second order filter with normalized coefficients.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+}
+
+// biquadState is the last two input and output samples of filter.
+type biquadState struct {
+	x1, x2, y1, y2 float64
+}
+
+// coefficients returns Butterworth filter for cutoff frequency. Two
+// cascaded Butterworth filters make Linkwitz-Riley filter.
+func (c *Crossover) coefficients(t filterType, cutoff float64) biquad {
+	w0 := 2 * math.Pi * cutoff / float64(c.sampleRate)
+	cos := math.Cos(w0)
+	// Butterworth Q is 1/sqrt(2).
+	alpha := math.Sin(w0) / math.Sqrt2
+	var b0, b1, b2 float64
+	switch t {
+	case lowpass:
+		b0, b1, b2 = (1-cos)/2, 1-cos, (1-cos)/2
+	case highpass:
+		b0, b1, b2 = (1+cos)/2, -(1 + cos), (1+cos)/2
+	case allpass:
+		b0, b1, b2 = 1-alpha, -2*cos, 1+alpha
+	}
+	a0 := 1 + alpha
+	return biquad{
+		b0: b0 / a0,
+		b1: b1 / a0,
+		b2: b2 / a0,
+		a1: -2 * cos / a0,
+		a2: (1 - alpha) / a0,
+	}
+}
+
+// process filters samples in place.
+func (f biquad) process(samples []float64, s *biquadState) {
+	for j, x := range samples {
+		y := f.b0*x + f.b1*s.x1 + f.b2*s.x2 - f.a1*s.y1 - f.a2*s.y2
+		s.x2, s.x1 = s.x1, x
+		s.y2, s.y1 = s.y1, y
+		samples[j] = y
+	}
+}
@@ -0,0 +1,184 @@
+package crossover_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/asset"
+	"github.com/dudk/phono/crossover"
+	"github.com/dudk/phono/mixer"
+	"github.com/dudk/phono/pipe"
+	"github.com/dudk/phono/tee"
+)
+
+const sampleRate = 44100
+
+// sine returns stereo buffer of sine with unity amplitude.
+func sine(frequency float64, size int) phono.Buffer {
+	b := phono.EmptyBuffer(2, phono.BufferSize(size))
+	for i := range b {
+		for j := range b[i] {
+			b[i][j] = math.Sin(2 * math.Pi * frequency * float64(j) / sampleRate)
+		}
+	}
+	return b
+}
+
+// rms returns root mean square of the second half of samples, so filters are settled.
+func rms(samples []float64) float64 {
+	var sum float64
+	half := samples[len(samples)/2:]
+	for _, v := range half {
+		sum += v * v
+	}
+	return math.Sqrt(sum / float64(len(half)))
+}
+
+// process passes buffer through band in chunks of buffer size.
+func process(t *testing.T, band *crossover.Band, b phono.Buffer, bufferSize int) phono.Buffer {
+	fn, err := band.Process("")
+	assert.Nil(t, err)
+	var result phono.Buffer
+	for i := 0; i < int(b.Size()); i += bufferSize {
+		out, err := fn(b.Slice(int64(i), bufferSize))
+		assert.Nil(t, err)
+		result = result.Append(out)
+	}
+	return result
+}
+
+func TestNew(t *testing.T) {
+	_, err := crossover.New(sampleRate)
+	assert.NotNil(t, err)
+	_, err = crossover.New(sampleRate, 1000, 500)
+	assert.NotNil(t, err)
+	_, err = crossover.New(sampleRate, 0)
+	assert.NotNil(t, err)
+	_, err = crossover.New(sampleRate, 30000)
+	assert.NotNil(t, err)
+	c, err := crossover.New(sampleRate, 500, 5000)
+	assert.Nil(t, err)
+	assert.Equal(t, 3, c.NumBands())
+	assert.Equal(t, 3, len(c.Bands()))
+}
+
+func TestCrossover(t *testing.T) {
+	c, err := crossover.New(sampleRate, 46*sampleRate/4096, 5000)
+	assert.Nil(t, err)
+	// frequencies have whole number of periods in the second half of buffer.
+	for _, periods := range []float64{5, 46, 186, 464, 1393} {
+		frequency := periods * sampleRate / 4096
+		sineRMS := math.Sqrt2 / 2
+		var sum phono.Buffer
+		levels := make([]float64, c.NumBands())
+		for i, band := range c.Bands() {
+			out := process(t, band, sine(frequency, 8192), 512)
+			levels[i] = rms(out[0])
+			if sum == nil {
+				sum = out
+				continue
+			}
+			for ch := range sum {
+				for j := range sum[ch] {
+					sum[ch][j] += out[ch][j]
+				}
+			}
+		}
+		// bands sum up to flat response.
+		assert.InDelta(t, sineRMS, rms(sum[0]), 1e-6, "frequency %v", frequency)
+		assert.InDelta(t, sineRMS, rms(sum[1]), 1e-6, "frequency %v", frequency)
+		switch periods {
+		case 5:
+			assert.True(t, levels[0] > 0.99*sineRMS)
+			assert.True(t, levels[2] < 0.001*sineRMS)
+		case 46:
+			// Linkwitz-Riley bands are -6 dB at cutoff.
+			assert.InDelta(t, sineRMS/2, levels[0], 0.01)
+			assert.InDelta(t, sineRMS/2, levels[1], 0.01)
+		case 1393:
+			assert.True(t, levels[2] > 0.99*sineRMS)
+			assert.True(t, levels[0] < 0.001*sineRMS)
+		}
+	}
+}
+
+func TestBandState(t *testing.T) {
+	c, err := crossover.New(sampleRate, 1000)
+	assert.Nil(t, err)
+	bands := c.Bands()
+	// state is kept between buffers.
+	whole := process(t, bands[1], sine(3000, 1024), 1024)
+	assert.Nil(t, bands[1].Reset(""))
+	chunked := process(t, bands[1], sine(3000, 1024), 100)
+	assert.InDeltaSlice(t, whole[0], chunked[0], 1e-12)
+}
+
+func TestCrossoverPipe(t *testing.T) {
+	c, err := crossover.New(sampleRate, 500, 5000)
+	assert.Nil(t, err)
+	in := asset.New()
+	in.Buffer = sine(1000, 4096)
+	split := tee.New(1)
+	input, err := pipe.New(
+		sampleRate,
+		pipe.WithPump(asset.NewPump(in, 512)),
+		pipe.WithSinks(split),
+	)
+	assert.Nil(t, err)
+	mix := mixer.New(512, 2)
+	var branches []*pipe.Pipe
+	for _, band := range c.Bands() {
+		branch, err := pipe.New(
+			sampleRate,
+			pipe.WithPump(split),
+			pipe.WithProcessors(band),
+			pipe.WithSinks(mix),
+		)
+		assert.Nil(t, err)
+		branches = append(branches, branch)
+	}
+	out := asset.New()
+	output, err := pipe.New(
+		sampleRate,
+		pipe.WithPump(mix),
+		pipe.WithSinks(out),
+	)
+	assert.Nil(t, err)
+
+	var errcs []<-chan error
+	for _, branch := range branches {
+		errcs = append(errcs, branch.Run())
+	}
+	outputErrc := output.Run()
+	assert.Nil(t, pipe.Wait(input.Run()))
+	for _, errc := range errcs {
+		assert.Nil(t, pipe.Wait(errc))
+	}
+	assert.Nil(t, pipe.Wait(outputErrc))
+
+	// sum of bands has only phase shift of allpass filters.
+	expected := sine(1000, 4096)
+	for _, f := range []float64{500, 5000} {
+		ap, err := crossover.New(sampleRate, f)
+		assert.Nil(t, err)
+		var sum phono.Buffer
+		for _, band := range ap.Bands() {
+			b := process(t, band, expected.Slice(0, 4096), 4096)
+			if sum == nil {
+				sum = b
+				continue
+			}
+			for i := range sum {
+				for j := range sum[i] {
+					sum[i][j] += b[i][j]
+				}
+			}
+		}
+		expected = sum
+	}
+	assert.Equal(t, expected.Size(), out.Size())
+	assert.InDeltaSlice(t, expected[0], out.Buffer[0], 1e-9)
+}
@@ -0,0 +1,54 @@
+// Package dcblock provides processor which removes DC offset.
+package dcblock
+
+import (
+	"math"
+
+	"github.com/dudk/phono"
+)
+
+// DCBlock removes DC offset with one-pole high-pass filter. Filter state is
+// kept per channel between buffers and cleared at the start of every run.
+// Buffers are processed in place.
+type DCBlock struct {
+	phono.UID
+	pole  float64
+	gain  float64      // normalizes gain at Nyquist frequency to unity.
+	state [][2]float64 // the last input and output sample of every channel.
+}
+
+// New creates new DC blocking filter with cutoff frequency in Hz.
+// A few Hz are enough to remove offset without affecting audible range.
+func New(sampleRate phono.SampleRate, cutoff float64) *DCBlock {
+	pole := math.Exp(-2 * math.Pi * cutoff / float64(sampleRate))
+	return &DCBlock{
+		UID:  phono.NewUID(),
+		pole: pole,
+		gain: (1 + pole) / 2,
+	}
+}
+
+// Process implements phono.Processor.
+func (d *DCBlock) Process(string) (phono.ProcessFunc, error) {
+	return func(b phono.Buffer) (phono.Buffer, error) {
+		if len(d.state) != len(b) {
+			d.state = make([][2]float64, len(b))
+		}
+		for i := range b {
+			x1, y1 := d.state[i][0], d.state[i][1]
+			for j, x := range b[i] {
+				y1 = d.gain*(x-x1) + d.pole*y1
+				x1 = x
+				b[i][j] = y1
+			}
+			d.state[i] = [2]float64{x1, y1}
+		}
+		return b, nil
+	}, nil
+}
+
+// Reset implements pipe.Resetter.
+func (d *DCBlock) Reset(string) error {
+	d.state = nil
+	return nil
+}
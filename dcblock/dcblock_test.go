@@ -0,0 +1,57 @@
+package dcblock_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/dcblock"
+)
+
+func TestDCBlock(t *testing.T) {
+	d := dcblock.New(44100, 5)
+	fn, err := d.Process("")
+	assert.Nil(t, err)
+
+	// sine with offset converges to sine without it.
+	var result []float64
+	for n := 0; n < 200; n++ {
+		b := phono.Buffer{make([]float64, 441), make([]float64, 441)}
+		for j := range b[0] {
+			s := math.Sin(2 * math.Pi * 1000 * float64(n*441+j) / 44100)
+			b[0][j] = 0.5 + 0.25*s
+			b[1][j] = -0.1
+		}
+		out, err := fn(b)
+		assert.Nil(t, err)
+		result = append(result, out[0]...)
+		if n == 199 {
+			for _, v := range out[1] {
+				assert.InDelta(t, 0, v, 1e-6)
+			}
+		}
+	}
+	var sum float64
+	last := result[len(result)-44100:]
+	for _, v := range last {
+		sum += v
+	}
+	assert.InDelta(t, 0, sum/float64(len(last)), 1e-3)
+	assert.InDelta(t, 0.25, peak(last), 0.01)
+
+	// state is cleared, so the first sample passes step through.
+	assert.Nil(t, d.Reset(""))
+	out, err := fn(phono.Buffer{[]float64{1}})
+	assert.Nil(t, err)
+	assert.True(t, out[0][0] > 0.99)
+}
+
+func peak(samples []float64) float64 {
+	var p float64
+	for _, v := range samples {
+		p = math.Max(p, math.Abs(v))
+	}
+	return p
+}
@@ -0,0 +1,78 @@
+// Package delay provides processor which delays signal by number of samples.
+package delay
+
+import (
+	"github.com/dudk/phono"
+)
+
+// Delay holds back the stream by a number of samples, e.g. to align dry
+// path with plugin latency. Output starts with silence of delay length and
+// held samples are sent when processor is drained, so output is longer
+// than input by delay. Buffers are processed in place and keep their size.
+type Delay struct {
+	phono.UID
+	samples int
+	held    [][]float64 // ring buffer of held samples per channel.
+	pos     int         // position of the oldest held sample.
+}
+
+// New creates new delay processor. Delay is defined in samples.
+func New(samples int) *Delay {
+	if samples < 0 {
+		samples = 0
+	}
+	return &Delay{
+		UID:     phono.NewUID(),
+		samples: samples,
+	}
+}
+
+// Samples returns delay in samples.
+func (d *Delay) Samples() int {
+	return d.samples
+}
+
+// Process implements phono.Processor.
+func (d *Delay) Process(string) (phono.ProcessFunc, error) {
+	return func(b phono.Buffer) (phono.Buffer, error) {
+		if d.samples == 0 {
+			return b, nil
+		}
+		if len(d.held) != len(b) {
+			d.held = phono.EmptyBuffer(b.NumChannels(), phono.BufferSize(d.samples))
+			d.pos = 0
+		}
+		pos := d.pos
+		for i := range b {
+			pos = d.pos
+			held := d.held[i]
+			for j, v := range b[i] {
+				b[i][j], held[pos] = held[pos], v
+				if pos++; pos == d.samples {
+					pos = 0
+				}
+			}
+		}
+		d.pos = pos
+		return b, nil
+	}, nil
+}
+
+// Drain implements pipe.Drainer. It returns held samples.
+func (d *Delay) Drain(string) (phono.Buffer, error) {
+	if d.held == nil {
+		return nil, phono.ErrEOP
+	}
+	result := make(phono.Buffer, len(d.held))
+	for i := range d.held {
+		result[i] = append(append(make([]float64, 0, d.samples), d.held[i][d.pos:]...), d.held[i][:d.pos]...)
+	}
+	d.held, d.pos = nil, 0
+	return result, nil
+}
+
+// Reset implements pipe.Resetter.
+func (d *Delay) Reset(string) error {
+	d.held, d.pos = nil, 0
+	return nil
+}
@@ -0,0 +1,96 @@
+package delay_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/delay"
+	"github.com/dudk/phono/mock"
+	"github.com/dudk/phono/pipe"
+)
+
+func TestDelay(t *testing.T) {
+	tests := []struct {
+		samples  int
+		in       []float64
+		sizes    []int
+		expected []float64
+	}{
+		{
+			samples:  2,
+			in:       []float64{1, 2, 3, 4, 5},
+			sizes:    []int{3, 2},
+			expected: []float64{0, 0, 1, 2, 3, 4, 5},
+		},
+		// delay longer than buffers.
+		{
+			samples:  5,
+			in:       []float64{1, 2, 3, 4},
+			sizes:    []int{2, 1, 1},
+			expected: []float64{0, 0, 0, 0, 0, 1, 2, 3, 4},
+		},
+		{
+			samples:  0,
+			in:       []float64{1, 2, 3},
+			sizes:    []int{3},
+			expected: []float64{1, 2, 3},
+		},
+	}
+	for _, test := range tests {
+		d := delay.New(test.samples)
+		fn, err := d.Process("")
+		assert.Nil(t, err)
+		var result []float64
+		pos := 0
+		for _, size := range test.sizes {
+			in := phono.Buffer{
+				append([]float64{}, test.in[pos:pos+size]...),
+				append([]float64{}, test.in[pos:pos+size]...),
+			}
+			pos += size
+			out, err := fn(in)
+			assert.Nil(t, err)
+			assert.Equal(t, phono.BufferSize(size), out.Size())
+			assert.Equal(t, out[0], out[1])
+			result = append(result, out[0]...)
+		}
+		for {
+			out, err := d.Drain("")
+			if err == phono.ErrEOP {
+				break
+			}
+			assert.Nil(t, err)
+			result = append(result, out[0]...)
+		}
+		assert.Equal(t, test.expected, result, "delay %v", test.samples)
+	}
+}
+
+func TestDelayPipe(t *testing.T) {
+	pump := &mock.Pump{
+		UID:         phono.NewUID(),
+		Limit:       10,
+		BufferSize:  10,
+		NumChannels: 2,
+		Value:       0.5,
+	}
+	sink := &mock.Sink{UID: phono.NewUID()}
+	d := delay.New(15)
+	p, err := pipe.New(
+		44100,
+		pipe.WithPump(pump),
+		pipe.WithProcessors(d),
+		pipe.WithSinks(sink),
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, pipe.Wait(p.Run()))
+	_, samples := sink.Count()
+	assert.Equal(t, int64(115), samples)
+
+	// held samples are dropped on reset.
+	assert.Nil(t, pipe.Wait(p.Run()))
+	_, samples = sink.Count()
+	assert.Equal(t, int64(115), samples)
+}
@@ -0,0 +1,94 @@
+// Package discard provides sink which drops buffers. It's used to measure
+// performance of pipe without overhead of output.
+package discard
+
+import (
+	"sync"
+	"time"
+
+	"github.com/dudk/phono"
+)
+
+// Sink drops received buffers and counts them. Counters are reset when
+// pipe starts a new run and can be read while pipe is running.
+type Sink struct {
+	phono.UID
+
+	m        sync.Mutex
+	messages int64
+	samples  int64
+	started  time.Time
+	elapsed  time.Duration
+	done     bool // run is finished, elapsed is final.
+}
+
+// New creates new discard sink.
+func New() *Sink {
+	return &Sink{
+		UID: phono.NewUID(),
+	}
+}
+
+// Reset implements pipe.Resetter. Counters and timer are reset.
+func (s *Sink) Reset(string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	s.messages, s.samples = 0, 0
+	s.started, s.elapsed, s.done = time.Now(), 0, false
+	return nil
+}
+
+// Sink returns function which counts received buffers.
+func (s *Sink) Sink(string) (phono.SinkFunc, error) {
+	return func(b phono.Buffer) error {
+		s.m.Lock()
+		defer s.m.Unlock()
+		if s.started.IsZero() {
+			s.started = time.Now()
+		}
+		s.messages++
+		s.samples += int64(b.Size())
+		return nil
+	}, nil
+}
+
+// Flush implements pipe.Flusher. Timer is stopped.
+func (s *Sink) Flush(string) error {
+	s.stop()
+	return nil
+}
+
+// Interrupt implements pipe.Interrupter. Timer is stopped when run is
+// cancelled, so elapsed time covers only processed buffers.
+func (s *Sink) Interrupt(string) error {
+	s.stop()
+	return nil
+}
+
+// stop fixes elapsed time of the run.
+func (s *Sink) stop() {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if !s.done && !s.started.IsZero() {
+		s.elapsed = time.Since(s.started)
+		s.done = true
+	}
+}
+
+// Count returns number of received messages and samples.
+func (s *Sink) Count() (messages int64, samples int64) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.messages, s.samples
+}
+
+// Elapsed returns duration of the run. While pipe is running, it's the
+// time since the start.
+func (s *Sink) Elapsed() time.Duration {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.done || s.started.IsZero() {
+		return s.elapsed
+	}
+	return time.Since(s.started)
+}
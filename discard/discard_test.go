@@ -0,0 +1,66 @@
+package discard_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/discard"
+	"github.com/dudk/phono/mock"
+	"github.com/dudk/phono/pipe"
+)
+
+func TestDiscard(t *testing.T) {
+	pump := &mock.Pump{
+		UID:         phono.NewUID(),
+		Limit:       10,
+		BufferSize:  512,
+		NumChannels: 2,
+		Interval:    time.Millisecond,
+	}
+	sink := discard.New()
+	p, err := pipe.New(
+		44100,
+		pipe.WithPump(pump),
+		pipe.WithSinks(sink),
+	)
+	assert.Nil(t, err)
+	for i := 0; i < 2; i++ {
+		assert.Nil(t, pipe.Wait(p.Run()))
+		messages, samples := sink.Count()
+		assert.Equal(t, int64(10), messages)
+		assert.Equal(t, int64(10*512), samples)
+		elapsed := sink.Elapsed()
+		assert.True(t, elapsed >= 10*time.Millisecond)
+		assert.Equal(t, elapsed, sink.Elapsed())
+	}
+	assert.Nil(t, pipe.Wait(p.Close()))
+}
+
+func TestDiscardCancel(t *testing.T) {
+	pump := &mock.Pump{
+		UID:         phono.NewUID(),
+		Limit:       1000,
+		BufferSize:  512,
+		NumChannels: 2,
+		Interval:    time.Millisecond,
+	}
+	sink := discard.New()
+	p, err := pipe.New(
+		44100,
+		pipe.WithPump(pump),
+		pipe.WithSinks(sink),
+	)
+	assert.Nil(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.NotNil(t, p.RunContext(ctx))
+	messages, _ := sink.Count()
+	assert.True(t, messages < 1000)
+	elapsed := sink.Elapsed()
+	assert.Equal(t, elapsed, sink.Elapsed())
+	assert.Nil(t, pipe.Wait(p.Close()))
+}
@@ -0,0 +1,94 @@
+// Package dither provides processor which reduces distortion of bit-depth reduction.
+package dither
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/dudk/phono"
+)
+
+// Type defines noise added to signal.
+type Type int
+
+const (
+	// TPDF adds noise with triangular distribution of 2 LSB peak-to-peak.
+	// It makes quantization error independent from signal.
+	TPDF Type = iota
+	// RPDF adds noise with rectangular distribution of 1 LSB peak-to-peak.
+	// It's quieter than TPDF, but noise level depends on signal.
+	RPDF
+	// ShapedTPDF adds TPDF noise and shapes quantization error to high
+	// frequencies, where it's less audible.
+	ShapedTPDF
+)
+
+// Dither adds noise to signal and quantizes it to target bit depth, so
+// integer sinks don't truncate low-level details. It must be the last
+// processor before sink. Buffers are processed in place.
+type Dither struct {
+	phono.UID
+	bitDepth int
+	kind     Type
+	scale    float64   // maximum integer value of target bit depth.
+	errors   []float64 // quantization error of the last sample in every channel.
+	rand     *rand.Rand
+}
+
+// New creates new dither processor for target bit depth.
+func New(bitDepth int, kind Type) *Dither {
+	return &Dither{
+		UID:      phono.NewUID(),
+		bitDepth: bitDepth,
+		kind:     kind,
+		scale:    float64(int(1)<<uint(bitDepth-1) - 1),
+		rand:     rand.New(rand.NewSource(1)),
+	}
+}
+
+// BitDepth returns target bit depth.
+func (d *Dither) BitDepth() int {
+	return d.bitDepth
+}
+
+// Type returns type of dither.
+func (d *Dither) Type() Type {
+	return d.kind
+}
+
+// Process implements phono.Processor.
+func (d *Dither) Process(string) (phono.ProcessFunc, error) {
+	return func(b phono.Buffer) (phono.Buffer, error) {
+		if len(d.errors) != len(b) {
+			d.errors = make([]float64, len(b))
+		}
+		for i := range b {
+			for j, v := range b[i] {
+				v *= d.scale
+				if d.kind == ShapedTPDF {
+					v -= d.errors[i]
+				}
+				q := math.Max(-d.scale, math.Min(d.scale, math.Round(v+d.noise())))
+				d.errors[i] = q - v
+				b[i][j] = q / d.scale
+			}
+		}
+		return b, nil
+	}, nil
+}
+
+// Reset implements pipe.Resetter.
+func (d *Dither) Reset(string) error {
+	d.errors = nil
+	return nil
+}
+
+// noise returns dither noise in LSB.
+func (d *Dither) noise() float64 {
+	switch d.kind {
+	case RPDF:
+		return d.rand.Float64() - 0.5
+	default:
+		return d.rand.Float64() - d.rand.Float64()
+	}
+}
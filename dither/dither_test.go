@@ -0,0 +1,42 @@
+package dither_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/dither"
+)
+
+func TestDither(t *testing.T) {
+	const (
+		bitDepth = 16
+		size     = 100000
+	)
+	lsb := 1 / float64(int(1)<<(bitDepth-1)-1)
+	for _, kind := range []dither.Type{dither.TPDF, dither.RPDF, dither.ShapedTPDF} {
+		d := dither.New(bitDepth, kind)
+		assert.Equal(t, bitDepth, d.BitDepth())
+		assert.Equal(t, kind, d.Type())
+		fn, err := d.Process("")
+		assert.Nil(t, err)
+
+		// signal below 1 LSB would be lost without dither.
+		b := phono.EmptyBuffer(1, size)
+		for j := range b[0] {
+			b[0][j] = 0.3 * lsb
+		}
+		out, err := fn(b)
+		assert.Nil(t, err)
+		var sum float64
+		for _, v := range out[0] {
+			// output is quantized to target bit depth.
+			steps := v / lsb
+			assert.InDelta(t, math.Round(steps), steps, 1e-6)
+			sum += v
+		}
+		assert.InDelta(t, 0.3*lsb, sum/size, 0.02*lsb, "type %v", kind)
+	}
+}
@@ -0,0 +1,197 @@
+// Package fade provides processor which fades signal in and out.
+package fade
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/dudk/phono"
+)
+
+// Curve defines shape of fade.
+type Curve int
+
+const (
+	// Linear changes gain linearly.
+	Linear Curve = iota
+	// Exponential changes gain linearly in decibels from -60 dB, so
+	// fade sounds even.
+	Exponential
+)
+
+// Fade applies fade-in at the start of the stream and fade-out at its end.
+// If length of stream is set, buffers are processed in place. Otherwise the
+// end isn't known until input is done, so output is delayed by fade-out
+// duration and the last samples are faded when processor is drained. Fade
+// can also be started manually with FadeOut. All channels have the same gain.
+type Fade struct {
+	phono.UID
+	in     int64 // fade-in length in samples.
+	out    int64 // fade-out length in samples.
+	curve  Curve
+	length int64 // length of stream in samples, 0 if unknown.
+
+	position int64        // position of the next output sample.
+	delayed  phono.Buffer // samples which can belong to fade-out.
+
+	m         sync.Mutex
+	requested bool          // FadeOut is called, but fade isn't started yet.
+	started   bool          // manual fade-out is started.
+	start     int64         // position of manual fade-out start.
+	done      chan struct{} // closed when manual fade-out is done.
+}
+
+// minGain is a level where exponential fade starts and ends.
+const minGain = 0.001
+
+// New creates new fade processor. Zero duration disables the fade.
+func New(sampleRate phono.SampleRate, in, out time.Duration, curve Curve) *Fade {
+	return &Fade{
+		UID:   phono.NewUID(),
+		in:    int64(float64(sampleRate) * in.Seconds()),
+		out:   int64(float64(sampleRate) * out.Seconds()),
+		curve: curve,
+	}
+}
+
+// SetLength sets length of the stream in samples, e.g. length of input
+// file. Fade-out ends at this position and output isn't delayed. Samples
+// after the end are silent. It must be set before processing is started.
+func (f *Fade) SetLength(length int64) {
+	f.length = length
+}
+
+// FadeOut starts fade-out from the next buffer. Signal is silent after fade
+// is done, so pipe can be stopped without click. Returned channel is closed
+// when fade is done.
+func (f *Fade) FadeOut() <-chan struct{} {
+	f.m.Lock()
+	defer f.m.Unlock()
+	if f.done == nil {
+		f.done = make(chan struct{})
+		f.requested = true
+		if f.out == 0 {
+			close(f.done)
+		}
+	}
+	return f.done
+}
+
+// Process implements phono.Processor.
+func (f *Fade) Process(string) (phono.ProcessFunc, error) {
+	return func(b phono.Buffer) (phono.Buffer, error) {
+		f.startFadeOut()
+		if f.length > 0 || f.out == 0 {
+			f.apply(b, f.length)
+			return b, nil
+		}
+		f.delayed = f.delayed.Append(b)
+		ready := int64(f.delayed.Size()) - f.out
+		if ready <= 0 {
+			return nil, nil
+		}
+		result := f.delayed.Slice(0, int(ready))
+		f.delayed = f.delayed.Slice(ready, int(f.out))
+		f.apply(result, 0)
+		return result, nil
+	}, nil
+}
+
+// Drain implements pipe.Drainer. It returns delayed samples with fade-out.
+func (f *Fade) Drain(string) (phono.Buffer, error) {
+	result := f.delayed
+	f.delayed = nil
+	if result == nil || result.Size() == 0 {
+		return nil, phono.ErrEOP
+	}
+	f.apply(result, f.position+int64(result.Size()))
+	return result, nil
+}
+
+// Reset implements pipe.Resetter.
+func (f *Fade) Reset(string) error {
+	f.position = 0
+	f.delayed = nil
+	f.m.Lock()
+	f.requested, f.started, f.start = false, false, 0
+	f.done = nil
+	f.m.Unlock()
+	return nil
+}
+
+// startFadeOut starts requested manual fade-out at current position.
+func (f *Fade) startFadeOut() {
+	f.m.Lock()
+	if f.requested {
+		f.requested = false
+		f.started = true
+		f.start = f.position
+	}
+	f.m.Unlock()
+}
+
+// apply multiplies buffer by fade gains and moves position. End is a
+// position where fade-out ends, 0 if it's unknown.
+func (f *Fade) apply(b phono.Buffer, end int64) {
+	f.m.Lock()
+	manual, start, done := f.started, f.start, f.done
+	f.m.Unlock()
+	size := int64(b.Size())
+	for j := int64(0); j < size; j++ {
+		pos := f.position + j
+		gain := 1.0
+		if pos < f.in {
+			gain *= f.shape(float64(pos) / float64(f.in))
+		}
+		if end > 0 {
+			gain *= f.fadeOut(end - pos)
+		}
+		if manual {
+			gain *= f.fadeOut(start + f.out - pos)
+		}
+		if gain == 1 {
+			continue
+		}
+		for i := range b {
+			b[i][j] *= gain
+		}
+	}
+	f.position += size
+	if manual && f.position >= start+f.out {
+		f.m.Lock()
+		if !closed(done) {
+			close(done)
+		}
+		f.m.Unlock()
+	}
+}
+
+// fadeOut returns gain of sample which is remaining samples before the end of fade-out.
+func (f *Fade) fadeOut(remaining int64) float64 {
+	if remaining <= 0 {
+		return 0
+	}
+	if remaining >= f.out {
+		return 1
+	}
+	return f.shape(float64(remaining) / float64(f.out))
+}
+
+// closed checks if channel is closed.
+func closed(done chan struct{}) bool {
+	select {
+	case <-done:
+		return true
+	default:
+		return false
+	}
+}
+
+// shape returns gain of fade curve at x in range from 0 to 1.
+func (f *Fade) shape(x float64) float64 {
+	if f.curve == Exponential {
+		return (math.Pow(minGain, 1-x) - minGain) / (1 - minGain)
+	}
+	return x
+}
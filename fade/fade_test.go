@@ -0,0 +1,92 @@
+package fade_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/fade"
+)
+
+// ones returns buffer of two channels filled with ones.
+func ones(size int) phono.Buffer {
+	b := phono.EmptyBuffer(2, phono.BufferSize(size))
+	for i := range b {
+		for j := range b[i] {
+			b[i][j] = 1
+		}
+	}
+	return b
+}
+
+// run processes buffers of size and drains processor.
+func run(t *testing.T, f *fade.Fade, sizes ...int) []float64 {
+	fn, err := f.Process("")
+	assert.Nil(t, err)
+	var result []float64
+	for _, size := range sizes {
+		out, err := fn(ones(size))
+		assert.Nil(t, err)
+		if out != nil {
+			assert.Equal(t, out[0], out[1])
+			result = append(result, out[0]...)
+		}
+	}
+	for {
+		out, err := f.Drain("")
+		if err == phono.ErrEOP {
+			break
+		}
+		assert.Nil(t, err)
+		result = append(result, out[0]...)
+	}
+	return result
+}
+
+func TestFade(t *testing.T) {
+	// 1 sample per millisecond.
+	f := fade.New(1000, 4*time.Millisecond, 4*time.Millisecond, fade.Linear)
+	f.SetLength(12)
+	assert.Equal(t, []float64{0, 0.25, 0.5, 0.75, 1, 1, 1, 1, 1, 0.75, 0.5, 0.25, 0, 0, 0}, run(t, f, 5, 5, 5))
+
+	// end is found when input is done.
+	f = fade.New(1000, 4*time.Millisecond, 4*time.Millisecond, fade.Linear)
+	assert.Equal(t, []float64{0, 0.25, 0.5, 0.75, 1, 1, 1, 1, 1, 0.75, 0.5, 0.25}, run(t, f, 4, 3, 2, 3))
+	assert.Nil(t, f.Reset(""))
+	// stream shorter than fades has both of them applied.
+	assert.Equal(t, []float64{0, 0.125, 0.125}, run(t, f, 3))
+
+	f = fade.New(1000, 2*time.Millisecond, 0, fade.Exponential)
+	result := run(t, f, 3)
+	assert.Equal(t, 0.0, result[0])
+	assert.InDelta(t, (math.Sqrt(0.001)-0.001)/(1-0.001), result[1], 1e-9)
+	assert.Equal(t, 1.0, result[2])
+}
+
+func TestFadeOut(t *testing.T) {
+	f := fade.New(1000, 0, 4*time.Millisecond, fade.Linear)
+	f.SetLength(100)
+	fn, err := f.Process("")
+	assert.Nil(t, err)
+	out, err := fn(ones(2))
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{1, 1}, []float64(out[0]))
+
+	done := f.FadeOut()
+	assert.Equal(t, done, f.FadeOut())
+	out, err = fn(ones(3))
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{1, 0.75, 0.5}, []float64(out[0]))
+	select {
+	case <-done:
+		t.Fatal("Fade-out is done too early")
+	default:
+	}
+	out, err = fn(ones(3))
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{0.25, 0, 0}, []float64(out[0]))
+	<-done
+}
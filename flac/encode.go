@@ -0,0 +1,168 @@
+package flac
+
+import (
+	"math/bits"
+
+	"github.com/mewkiz/flac/frame"
+)
+
+// Rice parameters which can be stored with residual coding methods.
+const (
+	maxRice1Param = 14 // 15 is reserved for escaped partition.
+	maxRice2Param = 30 // 31 is reserved for escaped partition.
+)
+
+// prediction defines how samples are encoded on compression level.
+type prediction struct {
+	maxOrder     int // maximum order of fixed predictor.
+	maxPartOrder int // maximum order of rice partitions.
+}
+
+// predictions maps compression levels to their settings. Level 0 stores samples as is.
+var predictions = [MaxLevel + 1]prediction{
+	1: {maxOrder: 1, maxPartOrder: 0},
+	2: {maxOrder: 2, maxPartOrder: 0},
+	3: {maxOrder: 2, maxPartOrder: 3},
+	4: {maxOrder: 3, maxPartOrder: 3},
+	5: {maxOrder: 4, maxPartOrder: 4},
+	6: {maxOrder: 4, maxPartOrder: 5},
+	7: {maxOrder: 4, maxPartOrder: 6},
+	8: {maxOrder: 4, maxPartOrder: 8},
+}
+
+// newSubframe chooses the smallest encoding of samples which is
+// available on compression level.
+func newSubframe(samples []int32, bitDepth int, level int) *frame.Subframe {
+	subframe := &frame.Subframe{
+		SubHeader: frame.SubHeader{Pred: frame.PredVerbatim},
+		Samples:   samples,
+		NSamples:  len(samples),
+	}
+	if level == 0 {
+		return subframe
+	}
+	if isConstant(samples) {
+		subframe.Pred = frame.PredConstant
+		return subframe
+	}
+	best := len(samples) * bitDepth
+	p := predictions[level]
+	for order := 0; order <= p.maxOrder && order < len(samples); order++ {
+		residuals := fixedResiduals(samples, order)
+		rice, method, size := riceCoding(residuals, len(samples), order, p.maxPartOrder)
+		// warm-up samples are stored as is.
+		size += order * bitDepth
+		if size < best {
+			best = size
+			subframe.Pred = frame.PredFixed
+			subframe.Order = order
+			subframe.ResidualCodingMethod = method
+			subframe.RiceSubframe = rice
+		}
+	}
+	return subframe
+}
+
+// isConstant checks if all samples have the same value.
+func isConstant(samples []int32) bool {
+	for _, v := range samples[1:] {
+		if v != samples[0] {
+			return false
+		}
+	}
+	return true
+}
+
+// fixedResiduals returns difference between samples and prediction of fixed predictor.
+func fixedResiduals(samples []int32, order int) []int32 {
+	coeffs := frame.FixedCoeffs[order]
+	residuals := make([]int32, len(samples)-order)
+	for i := order; i < len(samples); i++ {
+		var prediction int64
+		for j, c := range coeffs {
+			prediction += int64(c) * int64(samples[i-j-1])
+		}
+		residuals[i-order] = samples[i] - int32(prediction)
+	}
+	return residuals
+}
+
+// riceCoding finds partition order and rice parameters which result
+// in the smallest size of residuals. It returns size in bits.
+func riceCoding(residuals []int32, blockSize, order, maxPartOrder int) (*frame.RiceSubframe, frame.ResidualCodingMethod, int) {
+	folded := make([]uint32, len(residuals))
+	for i, r := range residuals {
+		folded[i] = uint32(r<<1) ^ uint32(r>>31)
+	}
+	var (
+		best       *frame.RiceSubframe
+		bestMethod frame.ResidualCodingMethod
+		bestSize   = -1
+	)
+	for partOrder := 0; partOrder <= maxPartOrder; partOrder++ {
+		nparts := 1 << uint(partOrder)
+		// partitions must have equal size and fit warm-up samples.
+		if blockSize%nparts != 0 || blockSize/nparts <= order {
+			break
+		}
+		rice := &frame.RiceSubframe{
+			PartOrder:  partOrder,
+			Partitions: make([]frame.RicePartition, nparts),
+		}
+		method := frame.ResidualCodingMethodRice1
+		size := 4 // partition order.
+		start := 0
+		for i := range rice.Partitions {
+			end := start + blockSize/nparts
+			if i == 0 {
+				end -= order
+			}
+			param, n := riceParam(folded[start:end])
+			if param > maxRice1Param {
+				method = frame.ResidualCodingMethodRice2
+			}
+			rice.Partitions[i].Param = param
+			size += n
+			start = end
+		}
+		if method == frame.ResidualCodingMethodRice2 {
+			size += 5 * nparts
+		} else {
+			size += 4 * nparts
+		}
+		if bestSize < 0 || size < bestSize {
+			best, bestMethod, bestSize = rice, method, size
+		}
+	}
+	// residual coding method.
+	return best, bestMethod, bestSize + 2
+}
+
+// riceParam returns rice parameter which results in the smallest
+// size of folded residuals and the size in bits. Optimal parameter is
+// close to logarithm of mean value, so only neighbours are checked.
+func riceParam(folded []uint32) (uint, int) {
+	if len(folded) == 0 {
+		return 0, 0
+	}
+	var sum uint64
+	for _, v := range folded {
+		sum += uint64(v)
+	}
+	estimate := bits.Len64(sum / uint64(len(folded)))
+	bestParam, bestSize := uint(0), -1
+	for k := estimate - 1; k <= estimate+1; k++ {
+		if k < 0 || k > maxRice2Param {
+			continue
+		}
+		// every residual has unary high bits, stop bit and k low bits.
+		size := len(folded) * (k + 1)
+		for _, v := range folded {
+			size += int(v >> uint(k))
+		}
+		if bestSize < 0 || size < bestSize {
+			bestParam, bestSize = uint(k), size
+		}
+	}
+	return bestParam, bestSize
+}
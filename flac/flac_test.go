@@ -0,0 +1,88 @@
+package flac_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/asset"
+	"github.com/dudk/phono/flac"
+	"github.com/dudk/phono/pipe"
+	"github.com/dudk/phono/test"
+	"github.com/dudk/phono/wav"
+)
+
+const bufferSize = 512
+
+func TestFlac(t *testing.T) {
+	tests := []struct {
+		bitDepth int
+		level    int
+	}{
+		{bitDepth: 16, level: 0},
+		{bitDepth: 16, level: 5},
+		{bitDepth: 24, level: flac.MaxLevel},
+	}
+	for _, tt := range tests {
+		// encode wav into flac.
+		wavPump, err := wav.NewPump(test.Data.Wav1, bufferSize)
+		assert.Nil(t, err)
+		original := asset.New()
+		sink, err := flac.NewSink(test.Out.Flac, wavPump.WavSampleRate(), wavPump.WavNumChannels(), tt.bitDepth, tt.level)
+		assert.Nil(t, err)
+		p, err := pipe.New(
+			wavPump.WavSampleRate(),
+			pipe.WithPump(wavPump),
+			pipe.WithSinks(sink, original),
+		)
+		assert.Nil(t, err)
+		assert.Nil(t, pipe.Wait(p.Run()))
+
+		// decode flac.
+		pump, err := flac.NewPump(test.Out.Flac, bufferSize)
+		assert.Nil(t, err)
+		assert.Equal(t, wavPump.WavSampleRate(), pump.SampleRate())
+		assert.Equal(t, wavPump.WavNumChannels(), pump.NumChannels())
+		assert.Equal(t, tt.bitDepth, pump.BitDepth())
+		assert.Equal(t, test.Data.Wav1Samples, pump.Samples())
+		decoded := asset.New()
+		p, err = pipe.New(
+			pump.SampleRate(),
+			pipe.WithPump(pump),
+			pipe.WithSinks(decoded),
+		)
+		assert.Nil(t, err)
+		assert.Nil(t, pipe.Wait(p.Run()))
+
+		assert.Equal(t, phono.BufferSize(test.Data.Wav1Samples), decoded.Size())
+		assert.Equal(t, original.NumChannels(), decoded.NumChannels())
+		delta := 2 / math.Pow(2, float64(tt.bitDepth-1))
+		for i := range original.Buffer {
+			for j := range original.Buffer[i] {
+				if !assert.InDelta(t, original.Buffer[i][j], decoded.Buffer[i][j], delta, "level %v bit depth %v", tt.level, tt.bitDepth) {
+					return
+				}
+			}
+		}
+	}
+}
+
+func TestNewSink(t *testing.T) {
+	_, err := flac.NewSink(test.Out.Flac, 44100, 2, 8, 5)
+	assert.NotNil(t, err)
+	_, err = flac.NewSink(test.Out.Flac, 44100, 2, 16, flac.MaxLevel+1)
+	assert.NotNil(t, err)
+	_, err = flac.NewSink(test.Out.Flac, 44100, 9, 16, 5)
+	assert.NotNil(t, err)
+}
+
+func TestSinkChannelsMismatch(t *testing.T) {
+	sink, err := flac.NewSink(test.Out.Flac, 44100, 2, 16, 5)
+	assert.Nil(t, err)
+	fn, err := sink.Sink("")
+	assert.Nil(t, err)
+	assert.NotNil(t, fn(phono.EmptyBuffer(1, bufferSize)))
+	assert.Nil(t, sink.Flush(""))
+}
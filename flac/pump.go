@@ -0,0 +1,116 @@
+// Package flac provides pump and sink to read and write flac files.
+package flac
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/dudk/phono"
+	"github.com/mewkiz/flac"
+)
+
+// Pump reads from flac file. Flac frames have their own size, so
+// decoded samples are collected into buffers of pump's buffer size.
+type Pump struct {
+	phono.UID
+	bufferSize  phono.BufferSize
+	sampleRate  phono.SampleRate
+	numChannels phono.NumChannels
+	bitDepth    int
+	stream      *flac.Stream
+	samples     [][]int32 // decoded samples which aren't sent yet.
+	done        bool
+	// Once for single-use.
+	once sync.Once
+}
+
+// NewPump creates a new flac pump and reads properties of the stream.
+func NewPump(path string, bufferSize phono.BufferSize) (*Pump, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := flac.New(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("Flac is not valid: %v", err)
+	}
+	return &Pump{
+		UID:         phono.NewUID(),
+		bufferSize:  bufferSize,
+		sampleRate:  phono.SampleRate(stream.Info.SampleRate),
+		numChannels: phono.NumChannels(stream.Info.NChannels),
+		bitDepth:    int(stream.Info.BitsPerSample),
+		stream:      stream,
+		samples:     make([][]int32, stream.Info.NChannels),
+	}, nil
+}
+
+// Pump returns function which reads buffers from flac. Last buffer
+// can be shorter than buffer size.
+func (p *Pump) Pump(string) (phono.PumpFunc, error) {
+	return func() (phono.Buffer, error) {
+		for !p.done && len(p.samples[0]) < int(p.bufferSize) {
+			f, err := p.stream.ParseNext()
+			if err == io.EOF {
+				p.done = true
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("Failed to decode flac: %v", err)
+			}
+			for i := range p.samples {
+				p.samples[i] = append(p.samples[i], f.Subframes[i].Samples...)
+			}
+		}
+		size := len(p.samples[0])
+		if size == 0 {
+			return nil, phono.ErrEOP
+		}
+		if size > int(p.bufferSize) {
+			size = int(p.bufferSize)
+		}
+		scale := float64(int(1) << uint(p.bitDepth-1))
+		b := phono.EmptyBuffer(p.numChannels, phono.BufferSize(size))
+		for i := range b {
+			for j := range b[i] {
+				b[i][j] = float64(p.samples[i][j]) / scale
+			}
+			p.samples[i] = p.samples[i][size:]
+		}
+		return b, nil
+	}, nil
+}
+
+// Flush closes the file.
+func (p *Pump) Flush(string) error {
+	return p.stream.Close()
+}
+
+// Reset implements pipe.Resetter.
+func (p *Pump) Reset(string) error {
+	return phono.SingleUse(&p.once)
+}
+
+// SampleRate returns flac's sample rate.
+func (p *Pump) SampleRate() phono.SampleRate {
+	return p.sampleRate
+}
+
+// NumChannels returns flac's number of channels.
+func (p *Pump) NumChannels() phono.NumChannels {
+	return p.numChannels
+}
+
+// BitDepth returns flac's bit depth.
+func (p *Pump) BitDepth() int {
+	return p.bitDepth
+}
+
+// Samples returns number of samples per channel declared in flac's stream
+// info. It's 0 if number of samples is unknown.
+func (p *Pump) Samples() int64 {
+	return int64(p.stream.Info.NSamples)
+}
@@ -0,0 +1,193 @@
+package flac
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"sync"
+
+	"github.com/dudk/phono"
+	"github.com/mewkiz/flac"
+	"github.com/mewkiz/flac/frame"
+	"github.com/mewkiz/flac/meta"
+)
+
+// Sink saves audio to flac file.
+type Sink struct {
+	phono.UID
+	numChannels phono.NumChannels
+	bitDepth    int
+	level       int
+	file        *file
+	encoder     *flac.Encoder
+	header      frame.Header
+	samples     [][]int32 // samples which don't fill the whole frame yet.
+	once        sync.Once
+}
+
+// blockSize is a number of samples per channel in flac frame.
+const blockSize = 4096
+
+// MaxLevel is the highest compression level.
+const MaxLevel = 8
+
+// channels maps number of channels to channels assignment of flac frame.
+var channels = map[phono.NumChannels]frame.Channels{
+	1: frame.ChannelsMono,
+	2: frame.ChannelsLR,
+	3: frame.ChannelsLRC,
+	4: frame.ChannelsLRLsRs,
+	5: frame.ChannelsLRCLsRs,
+	6: frame.ChannelsLRCLfeLsRs,
+	7: frame.ChannelsLRCLfeCsSlSr,
+	8: frame.ChannelsLRCLfeLsRsSlSr,
+}
+
+// NewSink creates new flac sink. Supported bit depths are 16 and 24.
+// Compression level is in range from 0 to MaxLevel, where 0 stores
+// samples without compression and higher levels search for better
+// prediction at the cost of encoding time.
+func NewSink(path string, sampleRate phono.SampleRate, numChannels phono.NumChannels, bitDepth int, level int) (*Sink, error) {
+	if bitDepth != 16 && bitDepth != 24 {
+		return nil, fmt.Errorf("Flac with bit depth %v is not supported", bitDepth)
+	}
+	if level < 0 || level > MaxLevel {
+		return nil, fmt.Errorf("Flac compression level %v is out of range", level)
+	}
+	ch, ok := channels[numChannels]
+	if !ok {
+		return nil, fmt.Errorf("Flac with %v channels is not supported", numChannels)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	file := &file{File: f, w: bufio.NewWriter(f)}
+	info := &meta.StreamInfo{
+		BlockSizeMin:  blockSize,
+		BlockSizeMax:  blockSize,
+		SampleRate:    uint32(sampleRate),
+		NChannels:     uint8(numChannels),
+		BitsPerSample: uint8(bitDepth),
+	}
+	encoder, err := flac.NewEncoder(file, info)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("Failed to create flac encoder: %v", err)
+	}
+	return &Sink{
+		UID:         phono.NewUID(),
+		numChannels: numChannels,
+		bitDepth:    bitDepth,
+		level:       level,
+		file:        file,
+		encoder:     encoder,
+		header: frame.Header{
+			HasFixedBlockSize: true,
+			SampleRate:        uint32(sampleRate),
+			Channels:          ch,
+			BitsPerSample:     uint8(bitDepth),
+		},
+		samples: make([][]int32, numChannels),
+	}, nil
+}
+
+// Reset is used to prevent additional runs of the sink.
+func (s *Sink) Reset(string) error {
+	return phono.SingleUse(&s.once)
+}
+
+// Flush writes the last frame and closes the file.
+func (s *Sink) Flush(string) error {
+	return s.close()
+}
+
+// Interrupt implements pipe.Interrupter. The last frame is written,
+// so the file is valid even if the stream ended early.
+func (s *Sink) Interrupt(string) error {
+	return s.close()
+}
+
+// close writes remaining samples, updates stream info and closes the file.
+func (s *Sink) close() error {
+	if len(s.samples[0]) > 0 {
+		if err := s.write(len(s.samples[0])); err != nil {
+			return err
+		}
+	}
+	if err := s.encoder.Close(); err != nil {
+		return fmt.Errorf("Failed to close flac: %v", err)
+	}
+	return nil
+}
+
+// Sink returns function which encodes buffers into flac frames.
+func (s *Sink) Sink(string) (phono.SinkFunc, error) {
+	return func(b phono.Buffer) error {
+		if nc := b.NumChannels(); nc != s.numChannels {
+			return fmt.Errorf("Flac sink expects %v channels, but received buffer has %v", s.numChannels, nc)
+		}
+		scale := float64(int(1)<<uint(s.bitDepth-1) - 1)
+		for i := range b {
+			for _, v := range b[i] {
+				v = math.Max(-1, math.Min(1, v))
+				s.samples[i] = append(s.samples[i], int32(math.Round(v*scale)))
+			}
+		}
+		for len(s.samples[0]) >= blockSize {
+			if err := s.write(blockSize); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+// write encodes frame of n samples.
+func (s *Sink) write(n int) error {
+	f := &frame.Frame{
+		Header:    s.header,
+		Subframes: make([]*frame.Subframe, len(s.samples)),
+	}
+	f.BlockSize = uint16(n)
+	for i := range s.samples {
+		f.Subframes[i] = newSubframe(s.samples[i][:n], s.bitDepth, s.level)
+	}
+	if err := s.encoder.WriteFrame(f); err != nil {
+		return fmt.Errorf("Failed to encode flac: %v", err)
+	}
+	for i := range s.samples {
+		s.samples[i] = append(s.samples[i][:0], s.samples[i][n:]...)
+	}
+	return nil
+}
+
+// file buffers writes to os.File. Encoder writes frames bit by bit
+// and seeks to the start when it's closed to update stream info.
+type file struct {
+	*os.File
+	w *bufio.Writer
+}
+
+// Write implements io.Writer.
+func (f *file) Write(p []byte) (int, error) {
+	return f.w.Write(p)
+}
+
+// Seek flushes buffered data before seek.
+func (f *file) Seek(offset int64, whence int) (int64, error) {
+	if err := f.w.Flush(); err != nil {
+		return 0, err
+	}
+	return f.File.Seek(offset, whence)
+}
+
+// Close flushes buffered data and closes the file.
+func (f *file) Close() error {
+	if err := f.w.Flush(); err != nil {
+		f.File.Close()
+		return err
+	}
+	return f.File.Close()
+}
@@ -0,0 +1,73 @@
+// Package gain provides processor which changes signal level.
+package gain
+
+import (
+	"math"
+	"sync"
+
+	"github.com/dudk/phono"
+)
+
+// Gain multiplies signal by linear gain factor. When gain is changed, it's
+// ramped over the next buffer to avoid zipper noise.
+type Gain struct {
+	phono.UID
+	current float64 // gain applied to the end of the last buffer.
+
+	m      sync.Mutex
+	target float64
+}
+
+// New creates new gain processor with gain in decibels.
+func New(db float64) *Gain {
+	g := &Gain{
+		UID: phono.NewUID(),
+	}
+	g.SetGain(db)
+	g.current = g.target
+	return g
+}
+
+// SetGain sets gain in decibels. It's safe to call while processing.
+func (g *Gain) SetGain(db float64) {
+	g.m.Lock()
+	g.target = FromDecibels(db)
+	g.m.Unlock()
+}
+
+// Process implements phono.Processor.
+func (g *Gain) Process(string) (phono.ProcessFunc, error) {
+	return func(b phono.Buffer) (phono.Buffer, error) {
+		g.m.Lock()
+		target := g.target
+		g.m.Unlock()
+
+		size := int(b.Size())
+		step := 0.0
+		if size > 0 {
+			step = (target - g.current) / float64(size)
+		}
+		result := phono.Buffer(make([][]float64, len(b)))
+		for i := range b {
+			result[i] = make([]float64, len(b[i]))
+			for j, v := range b[i] {
+				result[i][j] = v * (g.current + step*float64(j+1))
+			}
+		}
+		g.current = target
+		return result, nil
+	}, nil
+}
+
+// Reset implements pipe.Resetter. Gain isn't ramped at the start of run.
+func (g *Gain) Reset(string) error {
+	g.m.Lock()
+	g.current = g.target
+	g.m.Unlock()
+	return nil
+}
+
+// FromDecibels converts decibels into linear gain factor.
+func FromDecibels(db float64) float64 {
+	return math.Pow(10, db/20)
+}
@@ -0,0 +1,38 @@
+package gain_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/gain"
+)
+
+func TestGain(t *testing.T) {
+	g := gain.New(-6)
+	fn, err := g.Process("")
+	assert.Nil(t, err)
+
+	in := phono.Buffer{[]float64{1, 1, 1, 1}}
+	result, err := fn(in)
+	assert.Nil(t, err)
+	for _, v := range result[0] {
+		assert.InDelta(t, 0.501, v, 0.001)
+	}
+	assert.Equal(t, phono.Buffer{[]float64{1, 1, 1, 1}}, in)
+
+	// gain is ramped to the new value.
+	g.SetGain(0)
+	result, err = fn(in)
+	assert.Nil(t, err)
+	for i := 1; i < len(result[0]); i++ {
+		assert.True(t, result[0][i] > result[0][i-1])
+	}
+	assert.InDelta(t, 1, result[0][3], 1e-9)
+}
+
+func TestFromDecibels(t *testing.T) {
+	assert.Equal(t, 1.0, gain.FromDecibels(0))
+	assert.InDelta(t, 0.1, gain.FromDecibels(-20), 1e-9)
+}
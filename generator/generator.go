@@ -0,0 +1,197 @@
+// Package generator provides pump which produces test signals.
+package generator
+
+import (
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/dudk/phono"
+)
+
+// Waveform defines signal produced by generator.
+type Waveform int
+
+const (
+	// Sine is a sine wave of constant frequency.
+	Sine Waveform = iota
+	// WhiteNoise has equal power in all frequencies.
+	WhiteNoise
+	// PinkNoise has equal power in every octave.
+	PinkNoise
+	// Sweep is a sine wave which frequency changes logarithmically.
+	Sweep
+)
+
+// Default settings of generator.
+const (
+	defaultFrequency     = 440
+	defaultSweepFrom     = 20
+	defaultSweepTo       = 20000
+	defaultSweepDuration = 10 * time.Second
+	// pinkGain scales output of pink noise filter to full scale.
+	pinkGain = 0.11
+)
+
+// Generator is a pump which produces signal of waveform. Sine and sweep
+// have the same signal in all channels, noise is independent in every
+// channel. Phase is continuous between buffers. Every run starts from
+// the beginning, so generated signal is the same in every run.
+type Generator struct {
+	phono.UID
+	waveform    Waveform
+	sampleRate  phono.SampleRate
+	numChannels phono.NumChannels
+	bufferSize  phono.BufferSize
+	amplitude   float64
+	frequency   float64
+	sweepFrom   float64
+	sweepTo     float64
+	duration    time.Duration
+
+	position int64
+	phase    float64
+	rand     *rand.Rand
+	pink     [][7]float64 // state of pink noise filter per channel.
+}
+
+// New creates new generator with amplitude of full scale and unlimited
+// duration. Sine has frequency of 440 Hz and sweep goes from 20 Hz to 20 kHz.
+func New(waveform Waveform, sampleRate phono.SampleRate, numChannels phono.NumChannels, bufferSize phono.BufferSize) *Generator {
+	return &Generator{
+		UID:         phono.NewUID(),
+		waveform:    waveform,
+		sampleRate:  sampleRate,
+		numChannels: numChannels,
+		bufferSize:  bufferSize,
+		amplitude:   1,
+		frequency:   defaultFrequency,
+		sweepFrom:   defaultSweepFrom,
+		sweepTo:     defaultSweepTo,
+		rand:        rand.New(rand.NewSource(1)),
+		pink:        make([][7]float64, numChannels),
+	}
+}
+
+// SetAmplitude sets peak level of signal, where 1 is full scale.
+func (g *Generator) SetAmplitude(amplitude float64) {
+	g.amplitude = amplitude
+}
+
+// SetFrequency sets frequency of sine in Hz.
+func (g *Generator) SetFrequency(frequency float64) {
+	g.frequency = frequency
+}
+
+// SetSweep sets start and end frequencies of sweep in Hz.
+func (g *Generator) SetSweep(from, to float64) {
+	g.sweepFrom, g.sweepTo = from, to
+}
+
+// SetDuration limits duration of signal. Zero duration means that signal
+// is generated until pipe is stopped. Sweep goes through frequencies in
+// duration, unlimited sweep repeats every 10 seconds.
+func (g *Generator) SetDuration(d time.Duration) {
+	g.duration = d
+}
+
+// Reset implements pipe.Resetter.
+func (g *Generator) Reset(string) error {
+	g.position = 0
+	g.phase = 0
+	g.rand.Seed(1)
+	for i := range g.pink {
+		g.pink[i] = [7]float64{}
+	}
+	return nil
+}
+
+// Pump returns function which generates buffers. The last buffer can be shorter.
+func (g *Generator) Pump(string) (phono.PumpFunc, error) {
+	total := int64(float64(g.sampleRate) * g.duration.Seconds())
+	return func() (phono.Buffer, error) {
+		size := int64(g.bufferSize)
+		if total > 0 {
+			if g.position >= total {
+				return nil, phono.ErrEOP
+			}
+			if left := total - g.position; left < size {
+				size = left
+			}
+		}
+		b := phono.EmptyBuffer(g.numChannels, phono.BufferSize(size))
+		switch g.waveform {
+		case Sine:
+			g.sine(b, g.frequency)
+		case Sweep:
+			g.sweep(b)
+		case WhiteNoise:
+			g.whiteNoise(b)
+		case PinkNoise:
+			g.pinkNoise(b)
+		}
+		g.position += size
+		return b, nil
+	}, nil
+}
+
+// sine fills buffer with sine of frequency.
+func (g *Generator) sine(b phono.Buffer, frequency float64) {
+	step := 2 * math.Pi * frequency / float64(g.sampleRate)
+	for j := range b[0] {
+		v := g.amplitude * math.Sin(g.phase)
+		for i := range b {
+			b[i][j] = v
+		}
+		g.phase = math.Mod(g.phase+step, 2*math.Pi)
+	}
+}
+
+// sweep fills buffer with sine which frequency grows exponentially from
+// start to end frequency in sweep duration.
+func (g *Generator) sweep(b phono.Buffer) {
+	length := g.duration
+	if length <= 0 {
+		length = defaultSweepDuration
+	}
+	samples := float64(g.sampleRate) * length.Seconds()
+	ratio := g.sweepTo / g.sweepFrom
+	for j := range b[0] {
+		t := math.Mod(float64(g.position+int64(j)), samples) / samples
+		frequency := g.sweepFrom * math.Pow(ratio, t)
+		v := g.amplitude * math.Sin(g.phase)
+		for i := range b {
+			b[i][j] = v
+		}
+		g.phase = math.Mod(g.phase+2*math.Pi*frequency/float64(g.sampleRate), 2*math.Pi)
+	}
+}
+
+// whiteNoise fills buffer with uniformly distributed samples.
+func (g *Generator) whiteNoise(b phono.Buffer) {
+	for j := range b[0] {
+		for i := range b {
+			b[i][j] = g.amplitude * (2*g.rand.Float64() - 1)
+		}
+	}
+}
+
+// pinkNoise fills buffer with white noise filtered by Paul Kellet's
+// filter, which has -3 dB per octave slope.
+func (g *Generator) pinkNoise(b phono.Buffer) {
+	for j := range b[0] {
+		for i := range b {
+			white := 2*g.rand.Float64() - 1
+			s := &g.pink[i]
+			s[0] = 0.99886*s[0] + white*0.0555179
+			s[1] = 0.99332*s[1] + white*0.0750759
+			s[2] = 0.96900*s[2] + white*0.1538520
+			s[3] = 0.86650*s[3] + white*0.3104856
+			s[4] = 0.55000*s[4] + white*0.5329522
+			s[5] = -0.7616*s[5] - white*0.0168980
+			pink := s[0] + s[1] + s[2] + s[3] + s[4] + s[5] + s[6] + white*0.5362
+			s[6] = white * 0.115926
+			b[i][j] = g.amplitude * math.Max(-1, math.Min(1, pink*pinkGain))
+		}
+	}
+}
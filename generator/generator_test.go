@@ -0,0 +1,86 @@
+package generator_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/asset"
+	"github.com/dudk/phono/generator"
+	"github.com/dudk/phono/pipe"
+)
+
+const (
+	sampleRate = 44100
+	bufferSize = 512
+)
+
+func TestGenerator(t *testing.T) {
+	tests := []struct {
+		waveform generator.Waveform
+	}{
+		{waveform: generator.Sine},
+		{waveform: generator.WhiteNoise},
+		{waveform: generator.PinkNoise},
+		{waveform: generator.Sweep},
+	}
+	for _, test := range tests {
+		g := generator.New(test.waveform, sampleRate, 2, bufferSize)
+		g.SetAmplitude(0.5)
+		g.SetDuration(100 * time.Millisecond)
+		sink := asset.New()
+		p, err := pipe.New(
+			sampleRate,
+			pipe.WithPump(g),
+			pipe.WithSinks(sink),
+		)
+		assert.Nil(t, err)
+		assert.Nil(t, pipe.Wait(p.Run()))
+		assert.Equal(t, phono.NumChannels(2), sink.NumChannels())
+		assert.Equal(t, phono.BufferSize(4410), sink.Size())
+		var peak float64
+		for i := range sink.Buffer {
+			for _, v := range sink.Buffer[i] {
+				peak = math.Max(peak, math.Abs(v))
+			}
+		}
+		assert.True(t, peak > 0, "waveform %v", test.waveform)
+		assert.True(t, peak <= 0.5, "waveform %v", test.waveform)
+	}
+}
+
+func TestSinePhase(t *testing.T) {
+	g := generator.New(generator.Sine, sampleRate, 1, 100)
+	g.SetFrequency(1000)
+	fn, err := g.Pump("")
+	assert.Nil(t, err)
+	var samples []float64
+	for i := 0; i < 5; i++ {
+		b, err := fn()
+		assert.Nil(t, err)
+		samples = append(samples, b[0]...)
+	}
+	for j, v := range samples {
+		expected := math.Sin(2 * math.Pi * 1000 * float64(j) / sampleRate)
+		assert.InDelta(t, expected, v, 1e-9)
+	}
+
+	// every run starts from the beginning.
+	assert.Nil(t, g.Reset(""))
+	b, err := fn()
+	assert.Nil(t, err)
+	assert.Equal(t, samples[:100], []float64(b[0]))
+}
+
+func TestUnlimited(t *testing.T) {
+	g := generator.New(generator.Sweep, sampleRate, 1, bufferSize)
+	fn, err := g.Pump("")
+	assert.Nil(t, err)
+	for i := 0; i < 2000; i++ {
+		_, err := fn()
+		assert.Nil(t, err)
+	}
+}
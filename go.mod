@@ -6,11 +6,12 @@ require (
 	github.com/go-audio/wav v0.0.0-20181013172942-de841e69b884
 	github.com/gordonklaus/portaudio v0.0.0-20180817120803-00e7307ccd93
 	github.com/hajimehoshi/go-mp3 v0.1.1
+	github.com/mewkiz/flac v1.0.12
 	github.com/rs/xid v1.2.1
 	github.com/sirupsen/logrus v1.3.0
 	github.com/stretchr/testify v1.3.0
 	github.com/viert/lame v0.0.0-20190107091753-60caf1e722fd
 	go.uber.org/goleak v0.10.0
-	golang.org/x/crypto v0.0.0-20190103213133-ff983b9c42bc // indirect
-	golang.org/x/sys v0.0.0-20190107070147-cb59ee366067 // indirect
+	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519 // indirect
+	golang.org/x/sys v0.5.0 // indirect
 )
@@ -0,0 +1,94 @@
+// Package level provides processor which measures signal levels.
+package level
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/dudk/phono"
+)
+
+// Meter measures peak and RMS levels of every channel. Signal is passed through unchanged.
+type Meter struct {
+	phono.UID
+	sampleRate phono.SampleRate
+	release    time.Duration // time for peak hold to decay by 1/e.
+
+	m      sync.Mutex
+	levels []ChannelLevel
+}
+
+// ChannelLevel contains levels of single channel measured over the last buffer.
+// Values are linear, 1 is a full scale.
+type ChannelLevel struct {
+	Peak     float64
+	RMS      float64
+	PeakHold float64 // peak which decays with release time.
+}
+
+// New creates new meter. Release defines how fast the peak hold decays.
+func New(sampleRate phono.SampleRate, release time.Duration) *Meter {
+	return &Meter{
+		UID:        phono.NewUID(),
+		sampleRate: sampleRate,
+		release:    release,
+	}
+}
+
+// Process implements phono.Processor.
+func (m *Meter) Process(string) (phono.ProcessFunc, error) {
+	return func(b phono.Buffer) (phono.Buffer, error) {
+		m.measure(b)
+		return b, nil
+	}, nil
+}
+
+// Reset implements pipe.Resetter.
+func (m *Meter) Reset(string) error {
+	m.m.Lock()
+	m.levels = nil
+	m.m.Unlock()
+	return nil
+}
+
+// Levels returns levels of the last processed buffer.
+func (m *Meter) Levels() []ChannelLevel {
+	m.m.Lock()
+	defer m.m.Unlock()
+	levels := make([]ChannelLevel, len(m.levels))
+	copy(levels, m.levels)
+	return levels
+}
+
+// measure calculates levels of buffer.
+func (m *Meter) measure(b phono.Buffer) {
+	decay := 0.0
+	if m.release > 0 {
+		duration := m.sampleRate.DurationOf(int64(b.Size()))
+		decay = math.Exp(-float64(duration) / float64(m.release))
+	}
+
+	m.m.Lock()
+	defer m.m.Unlock()
+	if len(m.levels) != len(b) {
+		m.levels = make([]ChannelLevel, len(b))
+	}
+	for i := range b {
+		var peak, sum float64
+		for _, v := range b[i] {
+			v = math.Abs(v)
+			if v > peak {
+				peak = v
+			}
+			sum += v * v
+		}
+		l := &m.levels[i]
+		l.Peak = peak
+		l.RMS = 0
+		if len(b[i]) > 0 {
+			l.RMS = math.Sqrt(sum / float64(len(b[i])))
+		}
+		l.PeakHold = math.Max(peak, l.PeakHold*decay)
+	}
+}
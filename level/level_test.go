@@ -0,0 +1,39 @@
+package level_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/level"
+)
+
+func TestMeter(t *testing.T) {
+	meter := level.New(44100, 100*time.Millisecond)
+	fn, err := meter.Process("")
+	assert.Nil(t, err)
+
+	in := phono.Buffer{
+		[]float64{0.5, -0.5, 0.5, -0.5},
+		[]float64{0, 0, 0, -1},
+	}
+	out, err := fn(in)
+	assert.Nil(t, err)
+	assert.Equal(t, in, out)
+	levels := meter.Levels()
+	assert.Equal(t, 2, len(levels))
+	assert.Equal(t, 0.5, levels[0].Peak)
+	assert.InDelta(t, 0.5, levels[0].RMS, 1e-9)
+	assert.Equal(t, 1.0, levels[1].Peak)
+	assert.InDelta(t, 0.5, levels[1].RMS, 1e-9)
+
+	// peak hold decays after silence.
+	_, err = fn(phono.EmptyBuffer(2, 4410))
+	assert.Nil(t, err)
+	levels = meter.Levels()
+	assert.Equal(t, 0.0, levels[1].Peak)
+	assert.InDelta(t, math.Exp(-1), levels[1].PeakHold, 1e-9)
+}
@@ -0,0 +1,79 @@
+// Package limiter provides processor which keeps signal within full scale.
+package limiter
+
+import (
+	"math"
+	"sync/atomic"
+
+	"github.com/dudk/phono"
+)
+
+// Mode defines how samples beyond full scale are limited.
+type Mode int
+
+const (
+	// Clamp cuts samples to [-1, 1].
+	Clamp Mode = iota
+	// Soft saturates samples above the knee, so they smoothly approach full scale.
+	Soft
+)
+
+// knee is a level above which soft mode saturates signal.
+const knee = 0.8
+
+// Limiter limits samples to [-1, 1], so integer sinks don't overflow.
+// Buffers are processed in place.
+type Limiter struct {
+	phono.UID
+	mode    Mode
+	clipped int64 // number of samples beyond full scale.
+}
+
+// New creates new limiter.
+func New(mode Mode) *Limiter {
+	return &Limiter{
+		UID:  phono.NewUID(),
+		mode: mode,
+	}
+}
+
+// Process implements phono.Processor.
+func (l *Limiter) Process(string) (phono.ProcessFunc, error) {
+	return func(b phono.Buffer) (phono.Buffer, error) {
+		var clipped int64
+		for i := range b {
+			for j, v := range b[i] {
+				if v > 1 || v < -1 {
+					clipped++
+				}
+				b[i][j] = l.limit(v)
+			}
+		}
+		atomic.AddInt64(&l.clipped, clipped)
+		return b, nil
+	}, nil
+}
+
+// Clipped returns number of samples beyond full scale since the start of run.
+// It's safe to call while processing.
+func (l *Limiter) Clipped() int64 {
+	return atomic.LoadInt64(&l.clipped)
+}
+
+// Reset implements pipe.Resetter.
+func (l *Limiter) Reset(string) error {
+	atomic.StoreInt64(&l.clipped, 0)
+	return nil
+}
+
+// limit returns limited value of sample.
+func (l *Limiter) limit(v float64) float64 {
+	if l.mode == Soft {
+		if math.Abs(v) <= knee {
+			return v
+		}
+		over := (math.Abs(v) - knee) / (1 - knee)
+		return math.Copysign(knee+(1-knee)*math.Tanh(over), v)
+	}
+	return math.Max(-1, math.Min(1, v))
+}
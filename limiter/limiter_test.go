@@ -0,0 +1,33 @@
+package limiter_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/limiter"
+)
+
+func TestLimiter(t *testing.T) {
+	l := limiter.New(limiter.Clamp)
+	fn, err := l.Process("")
+	assert.Nil(t, err)
+	result, err := fn(phono.Buffer{[]float64{0.5, 1.5, -2, -0.3}})
+	assert.Nil(t, err)
+	assert.Equal(t, phono.Buffer{[]float64{0.5, 1, -1, -0.3}}, result)
+	assert.Equal(t, int64(2), l.Clipped())
+	assert.Nil(t, l.Reset(""))
+	assert.Equal(t, int64(0), l.Clipped())
+
+	l = limiter.New(limiter.Soft)
+	fn, err = l.Process("")
+	assert.Nil(t, err)
+	result, err = fn(phono.Buffer{[]float64{0.5, 0.9, 1.5, -10}})
+	assert.Nil(t, err)
+	assert.Equal(t, 0.5, result[0][0])
+	assert.True(t, result[0][1] > 0.8 && result[0][1] < 0.9)
+	assert.True(t, result[0][2] > result[0][1] && result[0][2] < 1)
+	assert.True(t, result[0][3] >= -1 && result[0][3] < -0.99)
+	assert.Equal(t, int64(2), l.Clipped())
+}
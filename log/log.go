@@ -31,3 +31,19 @@ func GetLogger() *logrus.Logger {
 	}
 	return l
 }
+
+// GetDebugLogger returns a new logger instance if PHONO_DEBUG is set.
+// Otherwise returned logger discards all messages.
+func GetDebugLogger() Logger {
+	if debug {
+		return GetLogger()
+	}
+	return discard{}
+}
+
+// discard is a logger which doesn't print anything.
+type discard struct{}
+
+func (discard) Debug(...interface{}) {}
+
+func (discard) Info(...interface{}) {}
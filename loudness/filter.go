@@ -0,0 +1,128 @@
+package loudness
+
+import (
+	"math"
+
+	"github.com/dudk/phono"
+)
+
+// channel contains filter state of single channel.
+type channel struct {
+	shelf    biquad
+	highPass biquad
+	history  []float64 // the last input samples of oversampling filter.
+}
+
+// biquad is a second order filter in direct form I.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+// Oversampling of true peak measurement.
+const (
+	oversampling = 4
+	phaseTaps    = 12
+)
+
+// interpolation contains coefficients of oversampling filter, one row per phase.
+var interpolation = newInterpolation()
+
+// newChannel creates K-weighting filters for sample rate. Coefficients are
+// calculated with BS.1770 filter parameters, so any sample rate is supported.
+func newChannel(sampleRate phono.SampleRate) channel {
+	fs := float64(sampleRate)
+
+	// high shelf which models acoustic effect of the head.
+	k := math.Tan(math.Pi * 1681.974450955533 / fs)
+	q := 0.7071752369554196
+	vh := math.Pow(10, 3.999843853973347/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	// high pass of revised low-frequency B-curve.
+	k = math.Tan(math.Pi * 38.13547087602444 / fs)
+	q = 0.5003270373238773
+	a0 = 1 + k/q + k*k
+	highPass := biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return channel{
+		shelf:    shelf,
+		highPass: highPass,
+		history:  make([]float64, phaseTaps),
+	}
+}
+
+// filter applies K-weighting to sample.
+func (c *channel) filter(v float64) float64 {
+	return c.highPass.process(c.shelf.process(v))
+}
+
+// process filters single sample.
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// peak returns the highest absolute value of oversampled samples.
+func (c *channel) peak(samples []float64) float64 {
+	var peak float64
+	for _, v := range samples {
+		peak = math.Max(peak, math.Abs(v))
+		copy(c.history[1:], c.history[:phaseTaps-1])
+		c.history[0] = v
+		for _, coefficients := range interpolation {
+			var sum float64
+			for j, h := range coefficients {
+				sum += h * c.history[j]
+			}
+			peak = math.Max(peak, math.Abs(sum))
+		}
+	}
+	return peak
+}
+
+// newInterpolation returns polyphase windowed sinc filter. Every phase
+// is normalized to unity gain.
+func newInterpolation() [][]float64 {
+	taps := oversampling * phaseTaps
+	center := float64(taps-1) / 2
+	result := make([][]float64, oversampling)
+	for p := range result {
+		result[p] = make([]float64, phaseTaps)
+		var sum float64
+		for j := range result[p] {
+			n := float64(j*oversampling + p)
+			x := (n - center) / oversampling
+			window := 0.5 - 0.5*math.Cos(2*math.Pi*(n+0.5)/float64(taps))
+			result[p][j] = sinc(x) * window
+			sum += result[p][j]
+		}
+		for j := range result[p] {
+			result[p][j] /= sum
+		}
+	}
+	return result
+}
+
+// sinc is a normalized sinc function.
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
@@ -0,0 +1,257 @@
+// Package loudness provides processor which measures loudness according to ITU-R BS.1770.
+package loudness
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/dudk/phono"
+)
+
+// Meter measures momentary, short-term and integrated loudness in LUFS
+// and true peak in dBTP. Signal is passed through unchanged. Channels of
+// 5.1 signal are weighted as defined in BS.1770, LFE is ignored. Other
+// layouts have equal weight for all channels.
+type Meter struct {
+	phono.UID
+	sampleRate phono.SampleRate
+	blockSize  int // samples in 100 ms block.
+
+	channels []channel
+	weights  []float64
+	energy   float64   // weighted energy of current block.
+	samples  int       // samples of current block.
+	blocks   []float64 // mean square of the last 30 blocks.
+	gating   []float64 // mean square of every 400 ms window, used for integrated loudness.
+
+	m               sync.Mutex
+	summaryCallback func(Summary)
+	momentary       float64
+	shortTerm       float64
+	maxMomentary    float64
+	maxShortTerm    float64
+	truePeak        float64 // linear.
+}
+
+// Summary contains loudness measured over the whole stream.
+type Summary struct {
+	Integrated   float64 // LUFS.
+	MaxMomentary float64 // LUFS.
+	MaxShortTerm float64 // LUFS.
+	TruePeak     float64 // dBTP.
+}
+
+// Measurement windows in number of 100 ms blocks.
+const (
+	blockDuration   = 100 * time.Millisecond
+	momentaryBlocks = 4
+	shortTermBlocks = 30
+)
+
+// Gates of integrated loudness.
+const (
+	absoluteGate = -70
+	relativeGate = -10
+)
+
+// New creates new loudness meter. Sample rate defines coefficients of
+// K-weighting filter.
+func New(sampleRate phono.SampleRate) *Meter {
+	m := &Meter{
+		UID:        phono.NewUID(),
+		sampleRate: sampleRate,
+		blockSize:  int(float64(sampleRate) * blockDuration.Seconds()),
+	}
+	m.clear()
+	return m
+}
+
+// SetSummaryCallback sets function which receives summary when stream is done.
+func (m *Meter) SetSummaryCallback(fn func(Summary)) {
+	m.m.Lock()
+	m.summaryCallback = fn
+	m.m.Unlock()
+}
+
+// Process implements phono.Processor.
+func (m *Meter) Process(string) (phono.ProcessFunc, error) {
+	return func(b phono.Buffer) (phono.Buffer, error) {
+		m.measure(b)
+		return b, nil
+	}, nil
+}
+
+// Reset implements pipe.Resetter.
+func (m *Meter) Reset(string) error {
+	m.m.Lock()
+	m.clear()
+	m.m.Unlock()
+	return nil
+}
+
+// Flush implements pipe.Flusher. Summary is sent to callback.
+func (m *Meter) Flush(string) error {
+	m.m.Lock()
+	fn := m.summaryCallback
+	m.m.Unlock()
+	if fn != nil {
+		fn(m.Summary())
+	}
+	return nil
+}
+
+// Momentary returns loudness of the last 400 ms in LUFS.
+func (m *Meter) Momentary() float64 {
+	m.m.Lock()
+	defer m.m.Unlock()
+	return m.momentary
+}
+
+// ShortTerm returns loudness of the last 3 seconds in LUFS.
+func (m *Meter) ShortTerm() float64 {
+	m.m.Lock()
+	defer m.m.Unlock()
+	return m.shortTerm
+}
+
+// Integrated returns gated loudness of the whole stream in LUFS.
+func (m *Meter) Integrated() float64 {
+	m.m.Lock()
+	defer m.m.Unlock()
+	return m.integrated()
+}
+
+// TruePeak returns the highest peak of oversampled signal in dBTP.
+func (m *Meter) TruePeak() float64 {
+	m.m.Lock()
+	defer m.m.Unlock()
+	return toDecibels(m.truePeak)
+}
+
+// Summary returns loudness measured since the start of the stream.
+func (m *Meter) Summary() Summary {
+	m.m.Lock()
+	defer m.m.Unlock()
+	return Summary{
+		Integrated:   m.integrated(),
+		MaxMomentary: m.maxMomentary,
+		MaxShortTerm: m.maxShortTerm,
+		TruePeak:     toDecibels(m.truePeak),
+	}
+}
+
+// clear resets measurements. Caller must hold the lock.
+func (m *Meter) clear() {
+	m.channels = nil
+	m.energy, m.samples = 0, 0
+	m.blocks, m.gating = nil, nil
+	m.momentary, m.shortTerm = math.Inf(-1), math.Inf(-1)
+	m.maxMomentary, m.maxShortTerm = math.Inf(-1), math.Inf(-1)
+	m.truePeak = 0
+}
+
+// measure filters buffer and updates measurements with every complete block.
+func (m *Meter) measure(b phono.Buffer) {
+	m.m.Lock()
+	defer m.m.Unlock()
+	if len(m.channels) != len(b) {
+		m.channels = make([]channel, len(b))
+		for i := range m.channels {
+			m.channels[i] = newChannel(m.sampleRate)
+		}
+		m.weights = weights(len(b))
+	}
+	for i := range b {
+		m.truePeak = math.Max(m.truePeak, m.channels[i].peak(b[i]))
+	}
+	for j := 0; j < int(b.Size()); j++ {
+		for i := range b {
+			v := m.channels[i].filter(b[i][j])
+			m.energy += m.weights[i] * v * v
+		}
+		m.samples++
+		if m.samples == m.blockSize {
+			m.addBlock(m.energy / float64(m.blockSize))
+			m.energy, m.samples = 0, 0
+		}
+	}
+}
+
+// addBlock updates loudness with mean square of 100 ms block. Caller must hold the lock.
+func (m *Meter) addBlock(meanSquare float64) {
+	m.blocks = append(m.blocks, meanSquare)
+	if len(m.blocks) > shortTermBlocks {
+		m.blocks = m.blocks[1:]
+	}
+	if len(m.blocks) >= momentaryBlocks {
+		power := mean(m.blocks[len(m.blocks)-momentaryBlocks:])
+		m.gating = append(m.gating, power)
+		m.momentary = toLUFS(power)
+		m.maxMomentary = math.Max(m.maxMomentary, m.momentary)
+	}
+	if len(m.blocks) == shortTermBlocks {
+		m.shortTerm = toLUFS(mean(m.blocks))
+		m.maxShortTerm = math.Max(m.maxShortTerm, m.shortTerm)
+	}
+}
+
+// integrated returns loudness of 400 ms windows above absolute
+// and relative gates. Caller must hold the lock.
+func (m *Meter) integrated() float64 {
+	absolute := gated(m.gating, fromLUFS(absoluteGate))
+	if len(absolute) == 0 {
+		return math.Inf(-1)
+	}
+	relative := fromLUFS(toLUFS(mean(absolute)) + relativeGate)
+	return toLUFS(mean(gated(absolute, relative)))
+}
+
+// gated returns values above threshold.
+func gated(values []float64, threshold float64) []float64 {
+	var result []float64
+	for _, v := range values {
+		if v > threshold {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// weights returns channel weights for number of channels.
+func weights(numChannels int) []float64 {
+	w := make([]float64, numChannels)
+	for i := range w {
+		w[i] = 1
+	}
+	// L, R, C, LFE, Ls, Rs.
+	if numChannels == 6 {
+		w[3] = 0
+		w[4], w[5] = 1.41, 1.41
+	}
+	return w
+}
+
+// mean returns average of values.
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// toLUFS converts weighted mean square into loudness.
+func toLUFS(power float64) float64 {
+	return -0.691 + 10*math.Log10(power)
+}
+
+// fromLUFS converts loudness into weighted mean square.
+func fromLUFS(lufs float64) float64 {
+	return math.Pow(10, (lufs+0.691)/10)
+}
+
+// toDecibels converts linear level into decibels.
+func toDecibels(v float64) float64 {
+	return 20 * math.Log10(v)
+}
@@ -0,0 +1,102 @@
+package loudness_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/generator"
+	"github.com/dudk/phono/loudness"
+	"github.com/dudk/phono/mock"
+	"github.com/dudk/phono/pipe"
+)
+
+func TestLoudness(t *testing.T) {
+	tests := []struct {
+		sampleRate phono.SampleRate
+		level      float64 // dBFS.
+	}{
+		{sampleRate: 48000, level: -23},
+		{sampleRate: 44100, level: -23},
+		{sampleRate: 48000, level: -33},
+	}
+	for _, test := range tests {
+		// stereo 1 kHz sine has loudness equal to its level.
+		g := generator.New(generator.Sine, test.sampleRate, 2, 512)
+		g.SetFrequency(1000)
+		g.SetAmplitude(math.Pow(10, test.level/20))
+		g.SetDuration(5 * time.Second)
+		meter := loudness.New(test.sampleRate)
+		var summary loudness.Summary
+		meter.SetSummaryCallback(func(s loudness.Summary) {
+			summary = s
+		})
+		sink := &mock.Sink{UID: phono.NewUID()}
+		p, err := pipe.New(
+			test.sampleRate,
+			pipe.WithPump(g),
+			pipe.WithProcessors(meter),
+			pipe.WithSinks(sink),
+		)
+		assert.Nil(t, err)
+		assert.Nil(t, pipe.Wait(p.Run()))
+
+		assert.InDelta(t, test.level, meter.Momentary(), 0.1)
+		assert.InDelta(t, test.level, meter.ShortTerm(), 0.1)
+		assert.InDelta(t, test.level, meter.Integrated(), 0.1)
+		assert.InDelta(t, test.level, meter.TruePeak(), 0.1)
+		assert.Equal(t, meter.Summary(), summary)
+		assert.InDelta(t, test.level, summary.MaxShortTerm, 0.1)
+
+		// signal is passed unchanged.
+		_, samples := sink.Count()
+		assert.Equal(t, int64(5*test.sampleRate), samples)
+	}
+}
+
+func TestGating(t *testing.T) {
+	meter := loudness.New(48000)
+	fn, err := meter.Process("")
+	assert.Nil(t, err)
+	assert.True(t, math.IsInf(meter.Integrated(), -1))
+
+	// silence is below absolute gate, so it doesn't change integrated loudness.
+	g := generator.New(generator.Sine, 48000, 2, 4800)
+	g.SetFrequency(1000)
+	g.SetAmplitude(math.Pow(10, -20.0/20))
+	pump, err := g.Pump("")
+	assert.Nil(t, err)
+	for i := 0; i < 30; i++ {
+		b, err := pump()
+		assert.Nil(t, err)
+		_, err = fn(b)
+		assert.Nil(t, err)
+	}
+	for i := 0; i < 30; i++ {
+		_, err = fn(phono.EmptyBuffer(2, 4800))
+		assert.Nil(t, err)
+	}
+	assert.InDelta(t, -20, meter.Integrated(), 0.5)
+
+	assert.Nil(t, meter.Reset(""))
+	assert.True(t, math.IsInf(meter.Integrated(), -1))
+	assert.True(t, math.IsInf(meter.Momentary(), -1))
+}
+
+func TestTruePeak(t *testing.T) {
+	// sine at quarter of sample rate with phase shift has sample peaks
+	// 3 dB below true peak.
+	b := phono.Buffer{make([]float64, 4800)}
+	for j := range b[0] {
+		b[0][j] = 0.5 * math.Sin(math.Pi/2*float64(j)+math.Pi/4)
+	}
+	meter := loudness.New(48000)
+	fn, err := meter.Process("")
+	assert.Nil(t, err)
+	_, err = fn(b)
+	assert.Nil(t, err)
+	assert.InDelta(t, 20*math.Log10(0.5), meter.TruePeak(), 0.5)
+}
@@ -7,7 +7,7 @@ import (
 	"github.com/dudk/phono/log"
 )
 
-// Mixer summs up multiple channels of messages into a single channel.
+// Mixer sums up multiple inputs into a single output.
 type Mixer struct {
 	phono.UID
 	log.Logger
@@ -35,22 +35,21 @@ type frame struct {
 	next     *frame
 }
 
-// sum returns mixed samplein.
+// sum returns mixed samples. Buffers are summed up, missing samples of
+// shorter buffers are treated as silence. Result has the maximum number
+// of channels among buffers, but not less than numChannels.
 func (f *frame) sum(numChannels phono.NumChannels, bufferSize phono.BufferSize) phono.Buffer {
-	var sum float64
-	var frames float64
-	result := phono.Buffer(make([][]float64, numChannels))
-	for nc := 0; nc < int(numChannels); nc++ {
-		result[nc] = make([]float64, 0, bufferSize)
-		for bs := 0; bs < int(bufferSize); bs++ {
-			sum = 0
-			frames = 0
-			// additional check to sum shorten blockin.
-			for i := 0; i < len(f.buffers) && len(f.buffers[i][nc]) > bs; i++ {
-				sum = sum + f.buffers[i][nc][bs]
-				frames++
+	for _, b := range f.buffers {
+		if nc := b.NumChannels(); nc > numChannels {
+			numChannels = nc
+		}
+	}
+	result := phono.EmptyBuffer(numChannels, bufferSize)
+	for _, b := range f.buffers {
+		for nc := range b {
+			for bs := 0; bs < int(bufferSize) && bs < len(b[nc]); bs++ {
+				result[nc][bs] += b[nc][bs]
 			}
-			result[nc] = append(result[nc], sum/frames)
 		}
 	}
 	f.buffers = nil
@@ -30,7 +30,7 @@ var (
 			Limit:    3,
 			value1:   0.5,
 			value2:   0.7,
-			sum:      0.6,
+			sum:      1.2,
 			messages: 3,
 			samples:  30,
 		},
@@ -38,7 +38,7 @@ var (
 			Limit:    10,
 			value1:   0.7,
 			value2:   0.9,
-			sum:      0.8,
+			sum:      1.6,
 			messages: 10,
 			samples:  100,
 		},
@@ -108,7 +108,7 @@ func TestMixer(t *testing.T) {
 		assert.Nil(t, err)
 		for i := range sink.Buffer {
 			for _, val := range sink.Buffer[i] {
-				assert.Equal(t, test.sum, val, fmt.Sprintf("Message: %v\n", i))
+				assert.InDelta(t, test.sum, val, 1e-9, fmt.Sprintf("Message: %v\n", i))
 			}
 		}
 		messageCount, sampleCount := sink.Count()
@@ -2,6 +2,7 @@ package mp3
 
 import (
 	"encoding/binary"
+	"fmt"
 	"io"
 	"os"
 
@@ -16,9 +17,14 @@ type Pump struct {
 	f          *os.File
 	d          *mp3.Decoder
 	bufferSize phono.BufferSize
+	data       []byte // decoded bytes of a single buffer.
+	samples    int64  // decoded samples per channel.
 	done       bool
 }
 
+// bytesPerSample is a size of 16-bit sample provided by decoder.
+const bytesPerSample = 2
+
 // NewPump creates new mp3 Pump.
 func NewPump(path string, bufferSize phono.BufferSize) (*Pump, error) {
 	f, err := os.Open(path)
@@ -39,34 +45,46 @@ func NewPump(path string, bufferSize phono.BufferSize) (*Pump, error) {
 	}, nil
 }
 
-// Pump reads buffer from mp3.
+// Pump reads buffer from mp3. Last buffer is padded with silence to the
+// buffer size, number of decoded samples is returned by Samples.
 func (p *Pump) Pump(string) (phono.PumpFunc, error) {
 	return func() (phono.Buffer, error) {
 		if p.done {
 			return nil, phono.ErrEOP
 		}
-
-		ints := make([]int, 0, int(p.bufferSize)*2)
-		var val int16
-		for len(ints) < int(p.bufferSize)*2 && !p.done {
-			if err := binary.Read(p.d, binary.LittleEndian, &val); err != nil {
-				if err == io.EOF {
-					p.done = true
-				} else {
-					return nil, err
-				}
+		numChannels := int(p.NumChannels())
+		frameSize := numChannels * bytesPerSample
+		if p.data == nil {
+			p.data = make([]byte, int(p.bufferSize)*frameSize)
+		}
+		n, err := io.ReadFull(p.d, p.data)
+		switch err {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			p.done = true
+			if n < frameSize {
+				return nil, phono.ErrEOP
 			}
-			ints = append(ints, int(val))
+		default:
+			return nil, fmt.Errorf("Failed to decode mp3: %v", err)
 		}
-		if len(ints)%2 == 1 {
-			ints = append(ints, 0)
+		frames := n / frameSize
+		ints := make([]int, int(p.bufferSize)*numChannels)
+		for i := 0; i < frames*numChannels; i++ {
+			ints[i] = int(int16(binary.LittleEndian.Uint16(p.data[i*bytesPerSample:])))
 		}
-		b := phono.EmptyBuffer(2, phono.BufferSize(len(ints)))
+		p.samples += int64(frames)
+		b := phono.EmptyBuffer(p.NumChannels(), p.bufferSize)
 		b.ReadInts(ints)
 		return b, nil
 	}, nil
 }
 
+// Samples returns number of decoded samples without padding.
+func (p *Pump) Samples() int64 {
+	return p.samples
+}
+
 // Flush all buffers.
 func (p *Pump) Flush(string) error {
 	return p.d.Close()
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/dudk/phono"
+	"github.com/dudk/phono/mock"
 	"github.com/dudk/phono/mp3"
 	"github.com/dudk/phono/pipe"
 	"github.com/dudk/phono/test"
@@ -17,12 +18,18 @@ func TestPump(t *testing.T) {
 	sampleRate := pump.SampleRate()
 	sink, err := mp3.NewSink(test.Out.Mp3, pump.SampleRate(), 2, 192, 2)
 	assert.Nil(t, err)
+	counter := &mock.Sink{UID: phono.NewUID()}
 	p, err := pipe.New(
 		sampleRate,
 		pipe.WithPump(pump),
-		pipe.WithSinks(sink),
+		pipe.WithSinks(sink, counter),
 	)
 	assert.Nil(t, err)
 	err = pipe.Wait(p.Run())
 	assert.Nil(t, err)
+
+	messages, samples := counter.Count()
+	assert.True(t, pump.Samples() > 0)
+	assert.Equal(t, messages*int64(bufferSize), samples)
+	assert.True(t, samples-pump.Samples() < int64(bufferSize))
 }
@@ -3,6 +3,7 @@ package mp3
 import (
 	"bytes"
 	"encoding/binary"
+	"fmt"
 	"os"
 	"sync"
 
@@ -13,12 +14,15 @@ import (
 // Sink allows to send data to mp3 files.
 type Sink struct {
 	phono.UID
-	f    *os.File
-	wr   *lame.LameWriter
-	once sync.Once
+	f           *os.File
+	wr          *lame.LameWriter
+	numChannels phono.NumChannels
+	once        sync.Once
 }
 
-// NewSink creates new Sink.
+// NewSink creates new Sink. If number of channels is zero, it's taken
+// from the first buffer, so sink follows processors which change number
+// of channels.
 func NewSink(path string, sampleRate phono.SampleRate, numChannels phono.NumChannels, bitRate int, quality int) (*Sink, error) {
 	f, err := os.Create(path)
 	if err != nil {
@@ -31,21 +35,44 @@ func NewSink(path string, sampleRate phono.SampleRate, numChannels phono.NumChan
 	}
 	s.wr.Encoder.SetBitrate(bitRate)
 	s.wr.Encoder.SetQuality(quality)
-	s.wr.Encoder.SetNumChannels(int(numChannels))
 	s.wr.Encoder.SetInSamplerate(int(sampleRate))
-	s.wr.Encoder.SetMode(lame.JOINT_STEREO)
 	s.wr.Encoder.SetVBR(lame.VBR_RH)
-	s.wr.Encoder.InitParams()
+	if numChannels > 0 {
+		s.init(numChannels)
+	}
 	return &s, nil
 }
 
+// init sets number of channels and initializes encoder.
+func (s *Sink) init(numChannels phono.NumChannels) {
+	s.numChannels = numChannels
+	s.wr.Encoder.SetNumChannels(int(numChannels))
+	if numChannels == 1 {
+		s.wr.Encoder.SetMode(lame.MONO)
+	} else {
+		s.wr.Encoder.SetMode(lame.JOINT_STEREO)
+	}
+	s.wr.Encoder.InitParams()
+}
+
 // Reset is used to prevent additional runs of the sink.
 func (s *Sink) Reset(string) error {
 	return phono.SingleUse(&s.once)
 }
 
-// Flush cleans up buffers.
+// Flush writes trailing frames and closes the file.
 func (s *Sink) Flush(string) error {
+	return s.close()
+}
+
+// Interrupt implements pipe.Interrupter. Trailing frames are written,
+// so the file is valid even if the stream ended early.
+func (s *Sink) Interrupt(string) error {
+	return s.close()
+}
+
+// close flushes encoder and closes the file.
+func (s *Sink) close() error {
 	err := s.wr.Close()
 	if err != nil {
 		return err
@@ -57,6 +84,12 @@ func (s *Sink) Flush(string) error {
 // Sink writes buffer into file.
 func (s *Sink) Sink(string) (phono.SinkFunc, error) {
 	return func(b phono.Buffer) error {
+		if s.numChannels == 0 {
+			s.init(b.NumChannels())
+		}
+		if nc := b.NumChannels(); nc != s.numChannels {
+			return fmt.Errorf("Mp3 sink expects %v channels, but received buffer has %v", s.numChannels, nc)
+		}
 		buf := new(bytes.Buffer)
 		ints := b.Ints()
 		for i := range ints {
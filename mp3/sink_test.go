@@ -29,3 +29,19 @@ func TestSink(t *testing.T) {
 	err = pipe.Wait(p.Run())
 	assert.Nil(t, err)
 }
+
+func TestSinkChannelsMismatch(t *testing.T) {
+	bufferSize := phono.BufferSize(512)
+	pump, err := wav.NewPump(test.Data.Wav1, bufferSize)
+	assert.Nil(t, err)
+	sink, err := mp3.NewSink(test.Out.Mp3, pump.WavSampleRate(), pump.WavNumChannels()+1, 192, 2)
+	assert.Nil(t, err)
+	p, err := pipe.New(
+		pump.WavSampleRate(),
+		pipe.WithPump(pump),
+		pipe.WithSinks(sink),
+	)
+	assert.Nil(t, err)
+	err = pipe.Wait(p.Run())
+	assert.NotNil(t, err)
+}
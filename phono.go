@@ -2,6 +2,7 @@ package phono
 
 import (
 	"errors"
+	"fmt"
 	"sync"
 	"time"
 
@@ -32,7 +33,8 @@ type (
 	// PumpFunc produces new buffer of data.
 	PumpFunc func() (Buffer, error)
 
-	// ProcessFunc consumes and returns new buffer of data.
+	// ProcessFunc consumes and returns new buffer of data. If nil buffer
+	// is returned, nothing is sent to the next pipe components.
 	ProcessFunc func(Buffer) (Buffer, error)
 
 	// SinkFunc consumes buffer of data.
@@ -241,6 +243,71 @@ func (b Buffer) ReadInts(ints []int) {
 	}
 }
 
+// Interleave converts deinterleaved samples into interleaved ones.
+// All channels must have the same length.
+func Interleave(in [][]float64) ([]float64, error) {
+	return InterleaveTo(nil, in)
+}
+
+// InterleaveTo converts deinterleaved samples into interleaved ones and
+// writes them into out. Out is reused if it has enough capacity, otherwise
+// new slice is allocated. Resulting slice is returned.
+func InterleaveTo(out []float64, in [][]float64) ([]float64, error) {
+	if len(in) == 0 {
+		return out[:0], nil
+	}
+	size := len(in[0])
+	for i := range in {
+		if len(in[i]) != size {
+			return nil, fmt.Errorf("Channel %v has %v samples, but channel 0 has %v", i, len(in[i]), size)
+		}
+	}
+	numChannels := len(in)
+	if cap(out) < size*numChannels {
+		out = make([]float64, size*numChannels)
+	}
+	out = out[:size*numChannels]
+	for i := range in {
+		for j, v := range in[i] {
+			out[j*numChannels+i] = v
+		}
+	}
+	return out, nil
+}
+
+// Deinterleave converts interleaved samples into deinterleaved ones.
+// Length of input must be divisible by number of channels.
+func Deinterleave(in []float64, numChannels int) ([][]float64, error) {
+	return DeinterleaveTo(nil, in, numChannels)
+}
+
+// DeinterleaveTo converts interleaved samples into deinterleaved ones and
+// writes them into out. Channels of out are reused if they have enough
+// capacity, otherwise new ones are allocated. Resulting slice is returned.
+func DeinterleaveTo(out [][]float64, in []float64, numChannels int) ([][]float64, error) {
+	if numChannels <= 0 {
+		return nil, fmt.Errorf("Number of channels must be positive, got %v", numChannels)
+	}
+	if len(in)%numChannels != 0 {
+		return nil, fmt.Errorf("Interleaved length %v isn't divisible by %v channels", len(in), numChannels)
+	}
+	size := len(in) / numChannels
+	if cap(out) < numChannels {
+		out = append(out[:cap(out)], make([][]float64, numChannels-cap(out))...)
+	}
+	out = out[:numChannels]
+	for i := range out {
+		if cap(out[i]) < size {
+			out[i] = make([]float64, size)
+		}
+		out[i] = out[i][:size]
+		for j := range out[i] {
+			out[i][j] = in[j*numChannels+i]
+		}
+	}
+	return out, nil
+}
+
 // EmptyBuffer returns an empty buffer of specified length
 func EmptyBuffer(numChannels NumChannels, bufferSize BufferSize) Buffer {
 	result := Buffer(make([][]float64, numChannels))
@@ -250,6 +317,64 @@ func EmptyBuffer(numChannels NumChannels, bufferSize BufferSize) Buffer {
 	return result
 }
 
+// bufferPools contains pools of buffers per dimensions.
+var bufferPools sync.Map // bufferDimensions: *sync.Pool
+
+type bufferDimensions struct {
+	numChannels NumChannels
+	size        BufferSize
+}
+
+// GetBuffer returns silent buffer from pool or allocates new one if pool
+// is empty. Caller owns returned buffer until it's passed further, e.g.
+// returned from ProcessFunc.
+//
+// Buffers are returned to pool with PutBuffer by the last component which
+// reads them. Pipe passes the same buffer to all sinks, so sink can put
+// buffer only if it's the only sink. Processor can put input buffer when
+// it returns another one, but only if no other component keeps input, e.g.
+// pump doesn't send the same buffer again.
+func GetBuffer(numChannels NumChannels, size BufferSize) Buffer {
+	if pool, ok := bufferPools.Load(bufferDimensions{numChannels, size}); ok {
+		if b, ok := pool.(*sync.Pool).Get().(Buffer); ok {
+			for i := range b {
+				for j := range b[i] {
+					b[i][j] = 0
+				}
+			}
+			return b
+		}
+	}
+	return newBuffer(numChannels, size)
+}
+
+// PutBuffer returns buffer to pool, so it can be reused by GetBuffer.
+// Buffer must not be used after that. Buffers with channels of different
+// size are dropped.
+func PutBuffer(b Buffer) {
+	if len(b) == 0 {
+		return
+	}
+	size := len(b[0])
+	for i := range b {
+		if len(b[i]) != size || cap(b[i]) != size {
+			return
+		}
+	}
+	pool, _ := bufferPools.LoadOrStore(bufferDimensions{b.NumChannels(), BufferSize(size)}, &sync.Pool{})
+	pool.(*sync.Pool).Put(b)
+}
+
+// newBuffer allocates buffer with channels in one slice.
+func newBuffer(numChannels NumChannels, size BufferSize) Buffer {
+	data := make([]float64, int(numChannels)*int(size))
+	b := make(Buffer, numChannels)
+	for i := range b {
+		b[i] = data[i*int(size) : (i+1)*int(size) : (i+1)*int(size)]
+	}
+	return b
+}
+
 // DurationOf returns time duration of passed samples for this sample rate.
 func (s SampleRate) DurationOf(v int64) time.Duration {
 	return time.Duration(float64(v) / float64(s) * float64(time.Second))
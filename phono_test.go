@@ -78,6 +78,22 @@ func TestSliceBuffer(t *testing.T) {
 	}
 }
 
+func TestBufferPool(t *testing.T) {
+	b := phono.GetBuffer(2, 4)
+	assert.Equal(t, phono.NumChannels(2), b.NumChannels())
+	assert.Equal(t, phono.BufferSize(4), b.Size())
+	b[0][0] = 1
+	phono.PutBuffer(b)
+
+	// reused buffer is silent.
+	b = phono.GetBuffer(2, 4)
+	assert.Equal(t, phono.EmptyBuffer(2, 4), b)
+
+	// buffer with channels of different size is dropped.
+	phono.PutBuffer(phono.Buffer{make([]float64, 4), make([]float64, 2)})
+	phono.PutBuffer(nil)
+}
+
 func TestSampleRate(t *testing.T) {
 	sampleRate := phono.SampleRate(44100)
 	expected := 500 * time.Millisecond
@@ -173,3 +189,42 @@ func TestInts(t *testing.T) {
 		}
 	}
 }
+
+func TestInterleave(t *testing.T) {
+	deinterleaved := [][]float64{
+		[]float64{1, 2, 3},
+		[]float64{4, 5, 6},
+	}
+	interleaved := []float64{1, 4, 2, 5, 3, 6}
+
+	result, err := phono.Interleave(deinterleaved)
+	assert.Nil(t, err)
+	assert.Equal(t, interleaved, result)
+	_, err = phono.Interleave([][]float64{[]float64{1, 2}, []float64{1}})
+	assert.NotNil(t, err)
+
+	// enough capacity, slice is reused.
+	out := make([]float64, 0, 8)
+	result, err = phono.InterleaveTo(out, deinterleaved)
+	assert.Nil(t, err)
+	assert.Equal(t, interleaved, result)
+	assert.Equal(t, &out[:1][0], &result[0])
+
+	back, err := phono.Deinterleave(interleaved, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, deinterleaved, back)
+	_, err = phono.Deinterleave(interleaved, 4)
+	assert.NotNil(t, err)
+	_, err = phono.Deinterleave(interleaved, 0)
+	assert.NotNil(t, err)
+
+	b := phono.EmptyBuffer(2, 3)
+	back, err = phono.DeinterleaveTo(b, interleaved, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, deinterleaved, back)
+	assert.Equal(t, &b[0][0], &back[0][0])
+	// mono buffer is extended.
+	back, err = phono.DeinterleaveTo(phono.EmptyBuffer(1, 1), interleaved, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, [][]float64{[]float64{1, 5}, []float64{4, 3}, []float64{2, 6}}, back)
+}
@@ -37,7 +37,7 @@ type Pipe struct {
 	metric   phono.Metric
 	params   params            //cahced params
 	feedback params            //cached feedback
-	errc     chan error        // errors channel
+	errc     <-chan error      // errors channel
 	events   chan eventMessage // event channel
 	cancel   chan struct{}     // cancellation channel
 
@@ -178,13 +178,14 @@ func (p *Pipe) start() {
 		errcList = append(errcList, errc)
 	}
 
-	sinkErrcList := p.broadcastToSinks(out)
+	sinkErrcList := p.broadcastToSinks(p.cancel, out)
 	errcList = append(errcList, sinkErrcList...)
-	p.errc = mergeErrors(errcList...)
+	p.errc = MergeErrors(errcList...)
 }
 
-// broadcastToSinks passes messages to all sinks.
-func (p *Pipe) broadcastToSinks(in <-chan message) []<-chan error {
+// broadcastToSinks passes messages to all sinks. Cancel channel is passed
+// explicitly, because broadcast can outlive the run and pipe replaces it.
+func (p *Pipe) broadcastToSinks(cancel chan struct{}, in <-chan message) []<-chan error {
 	//init errcList for sinks error channels
 	errcList := make([]<-chan error, 0, len(p.sinks))
 	//list of channels for broadcast
@@ -195,7 +196,7 @@ func (p *Pipe) broadcastToSinks(in <-chan message) []<-chan error {
 
 	//start broadcast
 	for i, s := range p.sinks {
-		errc := s.run(p.cancel, p.ID(), broadcasts[i], p.sampleRate, p.metric)
+		errc := s.run(cancel, p.ID(), broadcasts[i], p.sampleRate, p.metric)
 		errcList = append(errcList, errc)
 	}
 
@@ -216,7 +217,7 @@ func (p *Pipe) broadcastToSinks(in <-chan message) []<-chan error {
 				}
 				select {
 				case broadcasts[i] <- m:
-				case <-p.cancel:
+				case <-cancel:
 					return
 				}
 			}
@@ -226,10 +227,11 @@ func (p *Pipe) broadcastToSinks(in <-chan message) []<-chan error {
 	return errcList
 }
 
-// merge error channels from all components into one.
-func mergeErrors(errcList ...<-chan error) (errc chan error) {
+// MergeErrors multiplexes error channels into one. Returned channel is
+// closed after all input channels are closed. Nil channels are ignored.
+func MergeErrors(errcList ...<-chan error) <-chan error {
 	var wg sync.WaitGroup
-	errc = make(chan error, len(errcList))
+	errc := make(chan error, len(errcList))
 
 	//function to wait for error channel
 	output := func(ec <-chan error) {
@@ -238,8 +240,11 @@ func mergeErrors(errcList ...<-chan error) (errc chan error) {
 		}
 		wg.Done()
 	}
-	wg.Add(len(errcList))
 	for _, ec := range errcList {
+		if ec == nil {
+			continue
+		}
+		wg.Add(1)
 		go output(ec)
 	}
 
@@ -249,7 +254,7 @@ func mergeErrors(errcList ...<-chan error) (errc chan error) {
 		close(errc)
 	}()
 
-	return
+	return errc
 }
 
 // newMessage creates a new message with cached params.
@@ -278,6 +283,8 @@ func (e event) String() string {
 		return "resume"
 	case push:
 		return "params"
+	case stop:
+		return "stop"
 	}
 	return "unknown"
 }
@@ -329,6 +336,23 @@ func (p params) merge(source params) params {
 	return p
 }
 
+// carry merges params and feedback of source message into message.
+func (m message) carry(source message) message {
+	if len(source.params) > 0 {
+		if m.params == nil {
+			m.params = make(map[string][]phono.ParamFunc)
+		}
+		m.params = m.params.merge(source.params)
+	}
+	if len(source.feedback) > 0 {
+		if m.feedback == nil {
+			m.feedback = make(map[string][]phono.ParamFunc)
+		}
+		m.feedback = m.feedback.merge(source.feedback)
+	}
+	return m
+}
+
 func (p params) detach(id string) params {
 	if p == nil {
 		return nil
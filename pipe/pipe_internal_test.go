@@ -38,3 +38,64 @@ func TestMergeParams(t *testing.T) {
 	p.applyTo(pump.ID())
 	assert.Equal(t, newInterval, pump.Interval)
 }
+
+// bufferedProcessor sends messages through buffered channel.
+type bufferedProcessor struct {
+	*mock.Processor
+}
+
+func (bufferedProcessor) ChannelBuffer() int {
+	return 4
+}
+
+func TestChannelBuffer(t *testing.T) {
+	processor := bufferedProcessor{&mock.Processor{UID: phono.NewUID()}}
+	r, err := newProcessRunner("", processor)
+	assert.Nil(t, err)
+	in := make(chan message)
+	cancel := make(chan struct{})
+	out, _ := r.run(cancel, "", in, 44100, nil)
+	assert.Equal(t, 4, cap(out))
+	close(in)
+
+	r, err = newProcessRunner("", &mock.Processor{UID: phono.NewUID()})
+	assert.Nil(t, err)
+	in = make(chan message)
+	out, _ = r.run(cancel, "", in, 44100, nil)
+	assert.Equal(t, 0, cap(out))
+	close(in)
+	close(cancel)
+}
+
+// flushedProcessor counts flush calls.
+type flushedProcessor struct {
+	*mock.Processor
+	flushed int
+}
+
+func (p *flushedProcessor) Flush(string) error {
+	p.flushed++
+	return nil
+}
+
+func TestNilInput(t *testing.T) {
+	processor := &flushedProcessor{Processor: &mock.Processor{UID: phono.NewUID()}}
+	r, err := newProcessRunner("", processor)
+	assert.Nil(t, err)
+	cancel := make(chan struct{})
+	defer close(cancel)
+	out, errc := r.run(cancel, "", nil, 44100, nil)
+	select {
+	case _, ok := <-out:
+		assert.False(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("Output isn't closed")
+	}
+	for err := range errc {
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 1, processor.flushed)
+	messages, samples := processor.Count()
+	assert.Equal(t, int64(0), messages)
+	assert.Equal(t, int64(0), samples)
+}
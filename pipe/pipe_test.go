@@ -1,6 +1,7 @@
 package pipe_test
 
 import (
+	"context"
 	"testing"
 	"time"
 
@@ -124,6 +125,45 @@ func TestResume(t *testing.T) {
 	_ = pipe.Wait(p.Close())
 }
 
+// tailProcessor outputs a number of buffers after input is done.
+type tailProcessor struct {
+	mock.Processor
+	tail int
+}
+
+// Drain implements pipe.Drainer.
+func (p *tailProcessor) Drain(string) (phono.Buffer, error) {
+	if p.tail == 0 {
+		return nil, phono.ErrEOP
+	}
+	p.tail--
+	return phono.Buffer{make([]float64, 10)}, nil
+}
+
+func TestDrain(t *testing.T) {
+	pump := &mock.Pump{
+		UID:         phono.NewUID(),
+		Limit:       5,
+		BufferSize:  10,
+		NumChannels: 1,
+	}
+	proc := &tailProcessor{Processor: mock.Processor{UID: phono.NewUID()}, tail: 2}
+	sink := &mock.Sink{UID: phono.NewUID()}
+	p, err := pipe.New(
+		sampleRate,
+		pipe.WithPump(pump),
+		pipe.WithProcessors(proc),
+		pipe.WithSinks(sink),
+	)
+	assert.Nil(t, err)
+	err = pipe.Wait(p.Run())
+	assert.Nil(t, err)
+	messages, samples := sink.Count()
+	assert.Equal(t, int64(7), messages)
+	assert.Equal(t, int64(70), samples)
+	_ = pipe.Wait(p.Close())
+}
+
 // To test leaks we need to call close method with all possible circumstances.
 func TestLeaks(t *testing.T) {
 	// close while ready
@@ -156,6 +196,92 @@ func TestLeaks(t *testing.T) {
 	goleak.VerifyNoLeaks(t)
 }
 
+func TestRunContext(t *testing.T) {
+	pump := &mock.Pump{
+		UID:         phono.NewUID(),
+		Limit:       1000,
+		Interval:    time.Millisecond,
+		BufferSize:  10,
+		NumChannels: 1,
+	}
+	sink := &mock.Sink{UID: phono.NewUID()}
+	p, err := pipe.New(
+		sampleRate,
+		pipe.WithPump(pump),
+		pipe.WithSinks(sink),
+	)
+	assert.Nil(t, err)
+
+	// run is interrupted when context is done.
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err = p.RunContext(ctx)
+	assert.Equal(t, context.DeadlineExceeded, err)
+	messages, _ := sink.Count()
+	assert.True(t, messages < 1000)
+
+	// pipe is ready for the next run.
+	p.Push(pump.LimitParam(5))
+	err = p.RunContext(context.Background())
+	assert.Nil(t, err)
+	messages, _ = sink.Count()
+	assert.Equal(t, int64(5), messages)
+
+	// context is done when run is already completed.
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			p.Push(pump.LimitParam(1))
+			_ = p.RunContext(lateContext{Context: ctx, delay: 10 * time.Millisecond})
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("run context is blocked after run is completed")
+	}
+	_ = pipe.Wait(p.Close())
+}
+
+// lateContext reports that it's done after delay, so run is completed
+// by the time it's noticed.
+type lateContext struct {
+	context.Context
+	delay time.Duration
+}
+
+func (c lateContext) Done() <-chan struct{} {
+	time.Sleep(c.delay)
+	return c.Context.Done()
+}
+
+func TestMergeErrors(t *testing.T) {
+	errc1 := make(chan error, 1)
+	errc2 := make(chan error, 1)
+	merged := pipe.MergeErrors(errc1, nil, errc2)
+
+	errc1 <- phono.ErrEOP
+	close(errc1)
+	assert.Equal(t, phono.ErrEOP, <-merged)
+
+	// merged channel is open until all inputs are closed.
+	select {
+	case <-merged:
+		t.Fatal("merged channel is closed before all inputs")
+	case <-time.After(10 * time.Millisecond):
+	}
+	close(errc2)
+	_, ok := <-merged
+	assert.False(t, ok)
+
+	// no inputs.
+	_, ok = <-pipe.MergeErrors(nil)
+	assert.False(t, ok)
+}
+
 // This is a constructor of test pipe
 func newPipe(t *testing.T) *pipe.Pipe {
 	pump := &mock.Pump{
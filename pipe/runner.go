@@ -17,9 +17,11 @@ type pumpRunner struct {
 // processRunner represents processor's runner.
 type processRunner struct {
 	phono.Processor
-	fn  phono.ProcessFunc
-	in  <-chan message
-	out chan message
+	fn    phono.ProcessFunc
+	drain drain
+	emit  drain
+	in    <-chan message
+	out   chan message
 	hooks
 }
 
@@ -41,11 +43,34 @@ type Interrupter interface {
 	Interrupt(string) error
 }
 
+// Drainer defines processor which outputs buffers after its input is done,
+// e.g. tail of reverb. Drain is called until it returns phono.ErrEOP.
+type Drainer interface {
+	Drain(string) (phono.Buffer, error)
+}
+
+// Emitter defines processor which outputs buffers of different size than
+// its input, e.g. rebuffering. After every sent buffer, Emit is called
+// until it returns phono.ErrEOP.
+type Emitter interface {
+	Emit(string) (phono.Buffer, error)
+}
+
+// Bufferer defines processor which can send a few messages before the next
+// component receives them, so handoff doesn't happen for every buffer.
+// ChannelBuffer returns number of such messages and is read when pipe is started.
+type Bufferer interface {
+	ChannelBuffer() int
+}
+
 // Resetter defines component that must be resetted before consequent use.
 type Resetter interface {
 	Reset(string) error
 }
 
+// drain represents optional function which outputs remaining buffers of processor.
+type drain func(string) (phono.Buffer, error)
+
 // hook represents optional functions for components lyfecycle.
 type hook func(string) error
 
@@ -145,6 +170,31 @@ func flusher(i interface{}) hook {
 	return nil
 }
 
+// drainer checks if interface implements Drainer and if so, return it.
+func drainer(i interface{}) drain {
+	if v, ok := i.(Drainer); ok {
+		return v.Drain
+	}
+	return nil
+}
+
+// emitter checks if interface implements Emitter and if so, return it.
+func emitter(i interface{}) drain {
+	if v, ok := i.(Emitter); ok {
+		return v.Emit
+	}
+	return nil
+}
+
+// channelBuffer returns size of output channel of processor. It's 0 if
+// processor doesn't implement Bufferer.
+func channelBuffer(i interface{}) int {
+	if v, ok := i.(Bufferer); ok && v.ChannelBuffer() > 0 {
+		return v.ChannelBuffer()
+	}
+	return 0
+}
+
 // flusher checks if interface implements Flusher and if so, return it.
 func interrupter(i interface{}) hook {
 	if v, ok := i.(Interrupter); ok {
@@ -161,6 +211,17 @@ func resetter(i interface{}) hook {
 	return nil
 }
 
+// closedIfNil returns closed channel if input is nil, so runner doesn't
+// block on receive from nil channel.
+func closedIfNil(in <-chan message) <-chan message {
+	if in != nil {
+		return in
+	}
+	closed := make(chan message)
+	close(closed)
+	return closed
+}
+
 // newPumpRunner creates the closure. it's separated from run to have pre-run
 // logic executed in correct order for all components.
 func newPumpRunner(sourceID string, p phono.Pump) (*pumpRunner, error) {
@@ -242,16 +303,20 @@ func newProcessRunner(sourceID string, p phono.Processor) (*processRunner, error
 	r := processRunner{
 		fn:        fn,
 		Processor: p,
+		drain:     drainer(p),
+		emit:      emitter(p),
 		hooks:     bindHooks(p),
 	}
 	return &r, nil
 }
 
-// run the Processor runner.
+// run the Processor runner. Nil input is treated as closed one: nothing
+// is processed, but processor is drained and flushed and output is closed.
 func (r *processRunner) run(cancel chan struct{}, sourceID string, in <-chan message, sampleRate phono.SampleRate, metric phono.Metric) (<-chan message, <-chan error) {
 	errc := make(chan error, 1)
+	in = closedIfNil(in)
 	r.in = in
-	r.out = make(chan message)
+	r.out = make(chan message, channelBuffer(r.Processor))
 	go func() {
 		defer close(r.out)
 		defer close(errc)
@@ -259,13 +324,16 @@ func (r *processRunner) run(cancel chan struct{}, sourceID string, in <-chan mes
 		call(r.reset, sourceID, errc) // reset hook
 		var err error
 		var m message
+		var carried message // params of messages which weren't sent.
 		var ok bool
 		for {
 			// retrieve new message
 			select {
 			case m, ok = <-in:
 				if !ok {
-					call(r.flush, sourceID, errc) // flush hook
+					if r.drainTo(cancel, sourceID, errc) {
+						call(r.flush, sourceID, errc) // flush hook
+					}
 					return
 				}
 			case <-cancel:
@@ -284,6 +352,13 @@ func (r *processRunner) run(cancel chan struct{}, sourceID string, in <-chan mes
 
 			m.feedback.applyTo(r.ID()) // apply feedback
 
+			// nothing is sent, params are sent with the next buffer.
+			if m.Buffer == nil {
+				carried = carried.carry(m)
+				continue
+			}
+			m, carried = m.carry(carried), message{}
+
 			// send message further
 			select {
 			case r.out <- m:
@@ -291,11 +366,63 @@ func (r *processRunner) run(cancel chan struct{}, sourceID string, in <-chan mes
 				call(r.interrupt, sourceID, errc) // interrupt hook
 				return
 			}
+
+			if !r.emitTo(cancel, sourceID, errc) {
+				return
+			}
 		}
 	}()
 	return r.out, errc
 }
 
+// emitTo sends buffers which processor outputs in addition to processed
+// one. It returns false if processing was interrupted or error happened.
+func (r *processRunner) emitTo(cancel chan struct{}, sourceID string, errc chan error) bool {
+	if r.emit == nil {
+		return true
+	}
+	for {
+		b, err := r.emit(sourceID)
+		if err != nil {
+			if err == phono.ErrEOP {
+				return true
+			}
+			errc <- err
+			return false
+		}
+		select {
+		case r.out <- message{sourceID: sourceID, Buffer: b}:
+		case <-cancel:
+			call(r.interrupt, sourceID, errc) // interrupt hook
+			return false
+		}
+	}
+}
+
+// drainTo sends remaining buffers of processor further. It returns false if
+// processing was interrupted or error happened.
+func (r *processRunner) drainTo(cancel chan struct{}, sourceID string, errc chan error) bool {
+	if r.drain == nil {
+		return true
+	}
+	for {
+		b, err := r.drain(sourceID)
+		if err != nil {
+			if err == phono.ErrEOP {
+				return true
+			}
+			errc <- err
+			return false
+		}
+		select {
+		case r.out <- message{sourceID: sourceID, Buffer: b}:
+		case <-cancel:
+			call(r.interrupt, sourceID, errc) // interrupt hook
+			return false
+		}
+	}
+}
+
 // newSinkRunner creates the closure. it's separated from run to have pre-run
 // logic executed in correct order for all components.
 func newSinkRunner(sourceID string, s phono.Sink) (*sinkRunner, error) {
@@ -311,9 +438,10 @@ func newSinkRunner(sourceID string, s phono.Sink) (*sinkRunner, error) {
 	return &r, nil
 }
 
-// run the sink runner.
+// run the sink runner. Nil input is treated as closed one.
 func (r *sinkRunner) run(cancel chan struct{}, sourceID string, in <-chan message, sampleRate phono.SampleRate, metric phono.Metric) <-chan error {
 	errc := make(chan error, 1)
+	in = closedIfNil(in)
 	go func() {
 		defer close(errc)
 		meter := newMeter(r.ID(), sampleRate, metric)
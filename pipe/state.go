@@ -1,6 +1,7 @@
 package pipe
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
@@ -69,6 +70,7 @@ const (
 	push
 	measure
 	cancel
+	stop
 )
 
 // Run sends a run event into pipe.
@@ -113,6 +115,41 @@ func (p *Pipe) Resume() chan error {
 	return resumeEvent.target.errc
 }
 
+// RunContext runs pipe and blocks until it's done or first error occurs. If context
+// is done before, run is interrupted: all components are stopped and pipe is ready
+// for the next run. Context error is returned in this case.
+// Calling this method after pipe is closed causes a panic.
+func (p *Pipe) RunContext(ctx context.Context) error {
+	errc := p.Run()
+	for {
+		select {
+		case err, ok := <-errc:
+			if !ok {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		case <-ctx.Done():
+			_ = Wait(p.stop())
+			return ctx.Err()
+		}
+	}
+}
+
+// stop sends a stop event into pipe. Stop interrupts current run.
+func (p *Pipe) stop() chan error {
+	stopEvent := eventMessage{
+		event: stop,
+		target: target{
+			state: ready,
+			errc:  make(chan error, 1),
+		},
+	}
+	p.events <- stopEvent
+	return stopEvent.target.errc
+}
+
 // Close must be called to clean up pipe's resources.
 func (p *Pipe) Close() chan error {
 	resumeEvent := eventMessage{
@@ -127,7 +164,7 @@ func (p *Pipe) Close() chan error {
 }
 
 // Wait for state transition or first error to occur.
-func Wait(d chan error) error {
+func Wait(d <-chan error) error {
 	for err := range d {
 		if err != nil {
 			return err
@@ -166,6 +203,10 @@ func (p *Pipe) idle(s idleState, t target) (state, target) {
 			} else if e.hasTarget() {
 				t.dismiss()
 				t = e.target
+				// target is already reached, e.g. stop after run is done.
+				if s == newState && s == t.state {
+					t = t.dismiss()
+				}
 			}
 		}
 		if s != newState {
@@ -211,6 +252,9 @@ func (s idleReady) transition(p *Pipe, e eventMessage) (state, error) {
 	switch e.event {
 	case cancel:
 		return nil, nil
+	case stop:
+		// run is already done.
+		return s, nil
 	case push:
 		e.params.applyTo(p.ID())
 		p.params = p.params.merge(e.params)
@@ -237,6 +281,10 @@ func (s activeRunning) transition(p *Pipe, e eventMessage) (state, error) {
 		interrupt(p.cancel)
 		err := Wait(p.errc)
 		return nil, err
+	case stop:
+		interrupt(p.cancel)
+		_ = Wait(p.errc)
+		return ready, nil
 	case measure:
 		e.params.applyTo(p.ID())
 		p.feedback = p.feedback.merge(e.params)
@@ -266,6 +314,10 @@ func (s activePausing) transition(p *Pipe, e eventMessage) (state, error) {
 		interrupt(p.cancel)
 		err := Wait(p.errc)
 		return nil, err
+	case stop:
+		interrupt(p.cancel)
+		_ = Wait(p.errc)
+		return ready, nil
 	case measure:
 		e.params.applyTo(p.ID())
 		p.feedback = p.feedback.merge(e.params)
@@ -305,6 +357,10 @@ func (s idlePaused) transition(p *Pipe, e eventMessage) (state, error) {
 		interrupt(p.cancel)
 		err := Wait(p.errc)
 		return nil, err
+	case stop:
+		interrupt(p.cancel)
+		_ = Wait(p.errc)
+		return ready, nil
 	case push:
 		e.params.applyTo(p.ID())
 		p.params = p.params.merge(e.params)
@@ -0,0 +1,357 @@
+package portaudio
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/log"
+	"github.com/gordonklaus/portaudio"
+)
+
+// defaultRingSize is a number of buffers held by DeviceSink by default.
+const defaultRingSize = 4
+
+// DeviceSink plays pipe through default output device. Unlike Sink, it
+// doesn't write to device directly: buffers are put into ring buffer,
+// which is read by device callback. It allows to absorb jitter of pipe
+// when plugins are auditioned live. Device is started when ring is full
+// and every callback that doesn't have enough data is counted as underrun.
+type DeviceSink struct {
+	phono.UID
+	log log.Logger
+
+	sr       phono.SampleRate
+	bs       phono.BufferSize
+	nc       phono.NumChannels
+	ringSize int
+
+	m            sync.Mutex
+	logUnderruns bool
+	underruns    int64 // accessed atomically.
+	logged       int64
+	draining     int32 // accessed atomically.
+	started      bool
+	ring         *ring
+	buf          []float32
+	stream       *portaudio.Stream
+}
+
+// NewDeviceSink returns new sink which plays pipe through default output
+// device with provided sample rate and number of channels.
+func NewDeviceSink(bs phono.BufferSize, sr phono.SampleRate, nc phono.NumChannels) *DeviceSink {
+	return &DeviceSink{
+		UID:      phono.NewUID(),
+		log:      log.GetLogger(),
+		bs:       bs,
+		sr:       sr,
+		nc:       nc,
+		ringSize: defaultRingSize,
+	}
+}
+
+// SetRingSize sets number of buffers held by ring buffer. Bigger ring
+// absorbs more jitter, but increases latency. It must be called before
+// pipe is run. Values less than 1 are ignored.
+func (s *DeviceSink) SetRingSize(numBuffers int) {
+	if numBuffers < 1 {
+		return
+	}
+	s.m.Lock()
+	s.ringSize = numBuffers
+	s.m.Unlock()
+}
+
+// SetLogUnderruns enables logging of underruns. Underruns are logged from
+// sink function, not from device callback.
+func (s *DeviceSink) SetLogUnderruns(enabled bool) {
+	s.m.Lock()
+	s.logUnderruns = enabled
+	s.m.Unlock()
+}
+
+// Underruns returns number of device callbacks since the start of the
+// run which didn't have enough data and were padded with silence.
+func (s *DeviceSink) Underruns() int64 {
+	return atomic.LoadInt64(&s.underruns)
+}
+
+// Sink writes buffers to ring buffer. It also initializes portaudio api
+// with default stream.
+func (s *DeviceSink) Sink(string) (phono.SinkFunc, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	atomic.StoreInt64(&s.underruns, 0)
+	atomic.StoreInt32(&s.draining, 0)
+	s.logged = 0
+	s.started = false
+	s.ring = newRing(s.ringSize * int(s.bs) * int(s.nc))
+	s.buf = make([]float32, int(s.bs)*int(s.nc))
+	err := portaudio.Initialize()
+	if err != nil {
+		return nil, err
+	}
+	s.stream, err = portaudio.OpenDefaultStream(0, int(s.nc), float64(s.sr), int(s.bs), s.callback)
+	if err != nil {
+		portaudio.Terminate()
+		return nil, err
+	}
+	return func(b phono.Buffer) error {
+		if b.NumChannels() != s.nc {
+			return fmt.Errorf("Device sink expects %d channels, got %d", s.nc, b.NumChannels())
+		}
+		s.m.Lock()
+		defer s.m.Unlock()
+		samples := s.buf[:0]
+		for i := range b[0] {
+			for j := range b {
+				samples = append(samples, float32(b[j][i]))
+			}
+		}
+		s.buf = samples
+		if !s.started && s.ring.free() < len(samples) {
+			if err := s.start(); err != nil {
+				return err
+			}
+		}
+		// lock is released while writing, so flush and interrupt
+		// aren't blocked by full ring.
+		s.m.Unlock()
+		s.ring.write(samples)
+		s.m.Lock()
+		s.logUnderrunsLocked()
+		return nil
+	}, nil
+}
+
+// callback is called by portaudio when device needs samples.
+func (s *DeviceSink) callback(out []float32) {
+	missing := s.ring.read(out)
+	// silence after the last buffer isn't an underrun.
+	if missing > 0 && atomic.LoadInt32(&s.draining) == 0 {
+		atomic.AddInt64(&s.underruns, 1)
+	}
+}
+
+// start starts device stream. Must be called under lock.
+func (s *DeviceSink) start() error {
+	if err := s.stream.Start(); err != nil {
+		return err
+	}
+	s.started = true
+	return nil
+}
+
+// logUnderrunsLocked logs underruns happened since last call.
+// Must be called under lock.
+func (s *DeviceSink) logUnderrunsLocked() {
+	if !s.logUnderruns {
+		return
+	}
+	if underruns := s.Underruns(); underruns > s.logged {
+		s.log.Info(fmt.Sprintf("Device sink %v had %d underruns, %d total", s.ID(), underruns-s.logged, underruns))
+		s.logged = underruns
+	}
+}
+
+// Flush plays the rest of ring buffer and terminates portaudio structures.
+func (s *DeviceSink) Flush(string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.stream == nil {
+		return nil
+	}
+	atomic.StoreInt32(&s.draining, 1)
+	if !s.started {
+		// pipe ended before ring was filled.
+		if err := s.start(); err != nil {
+			return err
+		}
+	}
+	s.ring.wait()
+	s.logUnderrunsLocked()
+	return s.close(s.stream.Stop)
+}
+
+// Interrupt stops device without playing the rest of ring buffer.
+func (s *DeviceSink) Interrupt(string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.stream == nil {
+		return nil
+	}
+	atomic.StoreInt32(&s.draining, 1)
+	stop := s.stream.Abort
+	if !s.started {
+		stop = func() error { return nil }
+	}
+	return s.close(stop)
+}
+
+// close stops and closes stream and terminates portaudio api. It also
+// releases sink function blocked by full ring. Must be called under lock.
+func (s *DeviceSink) close(stop func() error) error {
+	s.ring.close()
+	s.started = false
+	stream := s.stream
+	s.stream = nil
+	err := stop()
+	if err != nil {
+		return err
+	}
+	err = stream.Close()
+	if err != nil {
+		return err
+	}
+	return portaudio.Terminate()
+}
+
+// DeviceSource captures pipe from input device. Device callback puts
+// samples into ring buffer and pump reads buffers of configured size from
+// it. If pipe doesn't keep up and ring is full, captured samples are
+// dropped and counted as overflow. Source runs until pipe is interrupted.
+type DeviceSource struct {
+	phono.UID
+
+	sr       phono.SampleRate
+	bs       phono.BufferSize
+	nc       phono.NumChannels
+	ringSize int
+
+	m         sync.Mutex
+	device    string
+	overflows int64 // accessed atomically.
+	ring      *ring
+	stream    *portaudio.Stream
+}
+
+// NewDeviceSource returns new pump which captures default input device with
+// provided sample rate and number of channels.
+func NewDeviceSource(bs phono.BufferSize, sr phono.SampleRate, nc phono.NumChannels) *DeviceSource {
+	return &DeviceSource{
+		UID:      phono.NewUID(),
+		bs:       bs,
+		sr:       sr,
+		nc:       nc,
+		ringSize: defaultRingSize,
+	}
+}
+
+// SetDevice selects input device by name. Empty name selects default input
+// device. It must be called before pipe is run.
+func (s *DeviceSource) SetDevice(name string) {
+	s.m.Lock()
+	s.device = name
+	s.m.Unlock()
+}
+
+// SetRingSize sets number of buffers held by ring buffer. It must be called
+// before pipe is run. Values less than 1 are ignored.
+func (s *DeviceSource) SetRingSize(numBuffers int) {
+	if numBuffers < 1 {
+		return
+	}
+	s.m.Lock()
+	s.ringSize = numBuffers
+	s.m.Unlock()
+}
+
+// Overflows returns number of device callbacks since the start of the run
+// which were dropped because ring buffer was full.
+func (s *DeviceSource) Overflows() int64 {
+	return atomic.LoadInt64(&s.overflows)
+}
+
+// Pump reads buffers captured from input device. It also initializes
+// portaudio api and starts input stream.
+func (s *DeviceSource) Pump(string) (phono.PumpFunc, error) {
+	s.m.Lock()
+	defer s.m.Unlock()
+	atomic.StoreInt64(&s.overflows, 0)
+	s.ring = newRing(s.ringSize * int(s.bs) * int(s.nc))
+	err := portaudio.Initialize()
+	if err != nil {
+		return nil, err
+	}
+	s.stream, err = s.open()
+	if err == nil {
+		err = s.stream.Start()
+	}
+	if err != nil {
+		if s.stream != nil {
+			s.stream.Close()
+			s.stream = nil
+		}
+		portaudio.Terminate()
+		return nil, err
+	}
+	ring := s.ring
+	samples := make([]float32, int(s.bs)*int(s.nc))
+	return func() (phono.Buffer, error) {
+		if !ring.readFull(samples) {
+			return nil, phono.ErrEOP
+		}
+		b := phono.EmptyBuffer(s.nc, s.bs)
+		for i := range b[0] {
+			for j := range b {
+				b[j][i] = float64(samples[i*int(s.nc)+j])
+			}
+		}
+		return b, nil
+	}, nil
+}
+
+// open opens input stream of selected device. Must be called under lock.
+func (s *DeviceSource) open() (*portaudio.Stream, error) {
+	if s.device == "" {
+		return portaudio.OpenDefaultStream(int(s.nc), 0, float64(s.sr), int(s.bs), s.callback)
+	}
+	devices, err := portaudio.Devices()
+	if err != nil {
+		return nil, err
+	}
+	for _, device := range devices {
+		if device.Name != s.device || device.MaxInputChannels == 0 {
+			continue
+		}
+		return portaudio.OpenStream(portaudio.StreamParameters{
+			Input: portaudio.StreamDeviceParameters{
+				Device:   device,
+				Channels: int(s.nc),
+				Latency:  device.DefaultLowInputLatency,
+			},
+			SampleRate:      float64(s.sr),
+			FramesPerBuffer: int(s.bs),
+		}, s.callback)
+	}
+	return nil, fmt.Errorf("Input device %v not found", s.device)
+}
+
+// callback is called by portaudio when device captured samples.
+func (s *DeviceSource) callback(in []float32) {
+	if !s.ring.tryWrite(in) {
+		atomic.AddInt64(&s.overflows, 1)
+	}
+}
+
+// Interrupt stops input device and terminates portaudio structures.
+func (s *DeviceSource) Interrupt(string) error {
+	s.m.Lock()
+	defer s.m.Unlock()
+	if s.stream == nil {
+		return nil
+	}
+	s.ring.close()
+	stream := s.stream
+	s.stream = nil
+	err := stream.Abort()
+	if err != nil {
+		return err
+	}
+	err = stream.Close()
+	if err != nil {
+		return err
+	}
+	return portaudio.Terminate()
+}
@@ -0,0 +1,132 @@
+package portaudio
+
+import (
+	"sync"
+)
+
+// ring is a ring buffer of interleaved samples. It's used to bridge device
+// callbacks and pipe: the side of device never waits, while the side of
+// pipe waits for space or data.
+type ring struct {
+	m      sync.Mutex
+	cond   *sync.Cond
+	buf    []float32
+	start  int // index of the first unread sample.
+	size   int // number of unread samples.
+	closed bool
+}
+
+func newRing(capacity int) *ring {
+	r := &ring{buf: make([]float32, capacity)}
+	r.cond = sync.NewCond(&r.m)
+	return r
+}
+
+// write puts samples into ring. It blocks until there is enough space
+// or ring is closed. Samples written after close are discarded.
+func (r *ring) write(samples []float32) {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for len(samples) > 0 {
+		for r.size == len(r.buf) && !r.closed {
+			r.cond.Wait()
+		}
+		if r.closed {
+			return
+		}
+		end := (r.start + r.size) % len(r.buf)
+		n := len(r.buf) - r.size
+		if n > len(samples) {
+			n = len(samples)
+		}
+		if end+n > len(r.buf) {
+			n = len(r.buf) - end
+		}
+		copy(r.buf[end:end+n], samples[:n])
+		r.size += n
+		samples = samples[n:]
+	}
+}
+
+// tryWrite puts samples into ring only if there is enough space for all
+// of them. It never blocks and returns false if samples are dropped.
+func (r *ring) tryWrite(samples []float32) bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+	if r.closed || len(r.buf)-r.size < len(samples) {
+		return false
+	}
+	for _, v := range samples {
+		r.buf[(r.start+r.size)%len(r.buf)] = v
+		r.size++
+	}
+	r.cond.Broadcast()
+	return true
+}
+
+// readFull fills out with unread samples. It blocks until there are
+// enough samples and returns false if ring is closed.
+func (r *ring) readFull(out []float32) bool {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for r.size < len(out) && !r.closed {
+		r.cond.Wait()
+	}
+	if r.closed {
+		return false
+	}
+	r.pull(out)
+	r.cond.Broadcast()
+	return true
+}
+
+// pull moves unread samples to out. Ring must have enough samples.
+// Must be called under lock.
+func (r *ring) pull(out []float32) {
+	for i := range out {
+		out[i] = r.buf[r.start]
+		r.start = (r.start + 1) % len(r.buf)
+	}
+	r.size -= len(out)
+}
+
+// read fills out with unread samples and returns number of samples
+// replaced with silence because ring didn't have enough data.
+func (r *ring) read(out []float32) int {
+	r.m.Lock()
+	defer r.m.Unlock()
+	read := len(out)
+	if read > r.size {
+		read = r.size
+	}
+	r.pull(out[:read])
+	for i := read; i < len(out); i++ {
+		out[i] = 0
+	}
+	r.cond.Broadcast()
+	return len(out) - read
+}
+
+// free returns number of samples which can be written without blocking.
+func (r *ring) free() int {
+	r.m.Lock()
+	defer r.m.Unlock()
+	return len(r.buf) - r.size
+}
+
+// wait blocks until all samples are read or ring is closed.
+func (r *ring) wait() {
+	r.m.Lock()
+	defer r.m.Unlock()
+	for r.size > 0 && !r.closed {
+		r.cond.Wait()
+	}
+}
+
+// close releases blocked writer and waiter.
+func (r *ring) close() {
+	r.m.Lock()
+	r.closed = true
+	r.m.Unlock()
+	r.cond.Broadcast()
+}
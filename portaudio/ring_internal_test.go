@@ -0,0 +1,80 @@
+package portaudio
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRing(t *testing.T) {
+	r := newRing(4)
+	r.write([]float32{1, 2, 3})
+	assert.Equal(t, 1, r.free())
+
+	out := make([]float32, 2)
+	assert.Equal(t, 0, r.read(out))
+	assert.Equal(t, []float32{1, 2}, out)
+
+	// write wraps around the end of ring.
+	r.write([]float32{4, 5, 6})
+	assert.Equal(t, 0, r.free())
+	out = make([]float32, 6)
+	assert.Equal(t, 2, r.read(out))
+	assert.Equal(t, []float32{3, 4, 5, 6, 0, 0}, out)
+}
+
+func TestRingBlocking(t *testing.T) {
+	r := newRing(2)
+	written := make(chan struct{})
+	go func() {
+		r.write([]float32{1, 2, 3, 4})
+		close(written)
+	}()
+	select {
+	case <-written:
+		t.Fatal("write didn't block on full ring")
+	case <-time.After(10 * time.Millisecond):
+	}
+	out := make([]float32, 2)
+	r.read(out)
+	<-written
+	assert.Equal(t, []float32{1, 2}, out)
+	r.read(out)
+	assert.Equal(t, []float32{3, 4}, out)
+	r.wait()
+
+	// closed ring releases writer and discards samples.
+	r.write([]float32{1, 2})
+	go r.close()
+	r.write([]float32{3})
+	assert.Equal(t, 0, r.free())
+}
+
+func TestRingCapture(t *testing.T) {
+	r := newRing(4)
+	assert.True(t, r.tryWrite([]float32{1, 2, 3}))
+	// samples which don't fit are dropped.
+	assert.False(t, r.tryWrite([]float32{4, 5}))
+	assert.True(t, r.tryWrite([]float32{4}))
+
+	out := make([]float32, 2)
+	assert.True(t, r.readFull(out))
+	assert.Equal(t, []float32{1, 2}, out)
+	assert.True(t, r.readFull(out))
+	assert.Equal(t, []float32{3, 4}, out)
+
+	read := make(chan bool)
+	go func() {
+		read <- r.readFull(out)
+	}()
+	assert.True(t, r.tryWrite([]float32{5}))
+	assert.True(t, r.tryWrite([]float32{6}))
+	assert.True(t, <-read)
+	assert.Equal(t, []float32{5, 6}, out)
+
+	// closed ring releases reader.
+	go r.close()
+	assert.False(t, r.readFull(out))
+	assert.False(t, r.tryWrite([]float32{7}))
+}
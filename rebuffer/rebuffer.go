@@ -0,0 +1,88 @@
+// Package rebuffer provides processor which changes size of buffers.
+package rebuffer
+
+import (
+	"github.com/dudk/phono"
+)
+
+// Rebuffer collects input buffers of any size and outputs buffers of fixed
+// size. Samples which don't fill the whole buffer are carried over to the
+// next input. When input is done, the last partial buffer is output. It
+// allows to process signal with different buffer size than pump has, e.g.
+// smaller buffers for vst2 plugin to reduce latency.
+type Rebuffer struct {
+	phono.UID
+	size   phono.BufferSize
+	buffer phono.Buffer // samples which aren't sent yet.
+}
+
+// New creates new rebuffer with output buffer size.
+func New(size phono.BufferSize) *Rebuffer {
+	return &Rebuffer{
+		UID:  phono.NewUID(),
+		size: size,
+	}
+}
+
+// Process implements phono.Processor. It returns nil if there are not enough
+// samples to fill the buffer.
+func (r *Rebuffer) Process(string) (phono.ProcessFunc, error) {
+	return func(b phono.Buffer) (phono.Buffer, error) {
+		r.compact(b.Size())
+		r.buffer = r.buffer.Append(b)
+		return r.next(), nil
+	}, nil
+}
+
+// Emit implements pipe.Emitter. It returns buffers while there are enough
+// samples to fill them.
+func (r *Rebuffer) Emit(string) (phono.Buffer, error) {
+	if b := r.next(); b != nil {
+		return b, nil
+	}
+	return nil, phono.ErrEOP
+}
+
+// Drain implements pipe.Drainer. It returns the last partial buffer.
+func (r *Rebuffer) Drain(string) (phono.Buffer, error) {
+	if r.buffer.Size() == 0 {
+		return nil, phono.ErrEOP
+	}
+	b := r.buffer
+	r.buffer = nil
+	return b, nil
+}
+
+// Reset implements pipe.Resetter.
+func (r *Rebuffer) Reset(string) error {
+	r.buffer = nil
+	return nil
+}
+
+// next cuts buffer of output size from collected samples.
+func (r *Rebuffer) next() phono.Buffer {
+	if r.size <= 0 || r.buffer.Size() < r.size {
+		return nil
+	}
+	b := make([][]float64, len(r.buffer))
+	for i := range r.buffer {
+		// capacity is limited, so appending to output can't overwrite
+		// samples which aren't sent yet.
+		b[i] = r.buffer[i][:r.size:r.size]
+		r.buffer[i] = r.buffer[i][r.size:]
+	}
+	return b
+}
+
+// compact moves samples which aren't sent yet to new storage with capacity
+// for n more samples. Sent buffers keep the old storage.
+func (r *Rebuffer) compact(n phono.BufferSize) {
+	if r.buffer == nil {
+		return
+	}
+	for i := range r.buffer {
+		c := make([]float64, len(r.buffer[i]), len(r.buffer[i])+int(n))
+		copy(c, r.buffer[i])
+		r.buffer[i] = c
+	}
+}
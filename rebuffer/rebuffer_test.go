@@ -0,0 +1,96 @@
+package rebuffer_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/mock"
+	"github.com/dudk/phono/pipe"
+	"github.com/dudk/phono/rebuffer"
+)
+
+func TestRebuffer(t *testing.T) {
+	tests := []struct {
+		bufferSize phono.BufferSize
+		size       phono.BufferSize
+		messages   int64
+	}{
+		// large input buffers are split.
+		{bufferSize: 300, size: 128, messages: 12},
+		// small input buffers are collected.
+		{bufferSize: 50, size: 128, messages: 2},
+		{bufferSize: 128, size: 128, messages: 5},
+	}
+	for _, test := range tests {
+		pump := &mock.Pump{
+			UID:         phono.NewUID(),
+			Limit:       5,
+			BufferSize:  test.bufferSize,
+			NumChannels: 2,
+			Value:       0.5,
+		}
+		sink := &mock.Sink{UID: phono.NewUID()}
+		p, err := pipe.New(
+			44100,
+			pipe.WithPump(pump),
+			pipe.WithProcessors(rebuffer.New(test.size)),
+			pipe.WithSinks(sink),
+		)
+		assert.Nil(t, err)
+		// rebuffer can be used in many runs.
+		for i := 0; i < 2; i++ {
+			assert.Nil(t, pipe.Wait(p.Run()))
+			messages, samples := sink.Count()
+			assert.Equal(t, test.messages, messages, "buffer size %v", test.bufferSize)
+			assert.Equal(t, int64(5*test.bufferSize), samples, "buffer size %v", test.bufferSize)
+		}
+		_ = pipe.Wait(p.Close())
+	}
+}
+
+func TestEmit(t *testing.T) {
+	r := rebuffer.New(4)
+	fn, err := r.Process("")
+	assert.Nil(t, err)
+
+	out, err := fn(phono.Buffer{[]float64{1, 2, 3}})
+	assert.Nil(t, err)
+	assert.Nil(t, out)
+	_, err = r.Emit("")
+	assert.Equal(t, phono.ErrEOP, err)
+
+	out, err = fn(phono.Buffer{[]float64{4, 5, 6, 7, 8, 9}})
+	assert.Nil(t, err)
+	assert.Equal(t, phono.Buffer{[]float64{1, 2, 3, 4}}, out)
+	out, err = r.Emit("")
+	assert.Nil(t, err)
+	assert.Equal(t, phono.Buffer{[]float64{5, 6, 7, 8}}, out)
+	_, err = r.Emit("")
+	assert.Equal(t, phono.ErrEOP, err)
+
+	out, err = r.Drain("")
+	assert.Nil(t, err)
+	assert.Equal(t, phono.Buffer{[]float64{9}}, out)
+	_, err = r.Drain("")
+	assert.Equal(t, phono.ErrEOP, err)
+}
+
+func TestOutputStorage(t *testing.T) {
+	r := rebuffer.New(2)
+	fn, err := r.Process("")
+	assert.Nil(t, err)
+
+	out, err := fn(phono.Buffer{[]float64{1, 2, 3}})
+	assert.Nil(t, err)
+	// appending to output mustn't overwrite samples which aren't sent yet.
+	out[0] = append(out[0], 0)
+	_, err = fn(phono.Buffer{[]float64{4, 5}})
+	assert.Nil(t, err)
+	assert.Equal(t, phono.Buffer{[]float64{1, 2, 0}}, out)
+
+	out, err = r.Drain("")
+	assert.Nil(t, err)
+	assert.Equal(t, phono.Buffer{[]float64{5}}, out)
+}
@@ -0,0 +1,167 @@
+// Package resample provides processor which converts sample rate of signal.
+package resample
+
+import (
+	"math"
+
+	"github.com/dudk/phono"
+)
+
+// Quality defines length of interpolation filter. Higher quality
+// has less aliasing, but requires more computations.
+type Quality int
+
+const (
+	// Low quality uses 8 samples on each side of interpolated sample.
+	Low Quality = 8
+	// Medium quality uses 16 samples on each side of interpolated sample.
+	Medium Quality = 16
+	// High quality uses 32 samples on each side of interpolated sample.
+	High Quality = 32
+)
+
+// Resampler converts signal from one sample rate to another with windowed
+// sinc interpolation. Ratio of sample rates can be non-integer, so output
+// buffers can have different size than input ones. Pipe components after
+// resampler must be configured with target sample rate.
+type Resampler struct {
+	phono.UID
+	step     float64 // distance between output samples in input samples.
+	cutoff   float64 // normalized cutoff frequency of filter.
+	halfTaps int
+
+	in       [][]float64 // input samples which are still needed for interpolation.
+	offset   int         // absolute position of the first kept input sample.
+	received int         // number of received input samples.
+	next     int         // index of the next output sample.
+}
+
+// New creates new resampler.
+func New(from, to phono.SampleRate, quality Quality) *Resampler {
+	cutoff := 1.0
+	// filter out frequencies above new Nyquist frequency.
+	if to < from {
+		cutoff = float64(to) / float64(from)
+	}
+	return &Resampler{
+		UID:      phono.NewUID(),
+		step:     float64(from) / float64(to),
+		cutoff:   cutoff,
+		halfTaps: int(quality),
+	}
+}
+
+// Process implements phono.Processor.
+func (r *Resampler) Process(string) (phono.ProcessFunc, error) {
+	return func(b phono.Buffer) (phono.Buffer, error) {
+		if len(b) != len(r.in) {
+			r.init(b.NumChannels())
+		}
+		for i := range r.in {
+			r.in[i] = append(r.in[i], b[i]...)
+		}
+		r.received += int(b.Size())
+		return r.resample(), nil
+	}, nil
+}
+
+// Drain implements pipe.Drainer. Last samples are interpolated with silence.
+func (r *Resampler) Drain(string) (phono.Buffer, error) {
+	if r.in == nil || r.position() >= float64(r.received) {
+		return nil, phono.ErrEOP
+	}
+	for i := range r.in {
+		r.in[i] = append(r.in[i], make([]float64, r.halfTaps)...)
+	}
+	result := r.resample()
+	r.in = nil
+	return result, nil
+}
+
+// Reset implements pipe.Resetter.
+func (r *Resampler) Reset(string) error {
+	r.in = nil
+	return nil
+}
+
+// init prepends input with silence, so the first output
+// sample is interpolated at the first input sample.
+func (r *Resampler) init(numChannels phono.NumChannels) {
+	r.in = make([][]float64, numChannels)
+	for i := range r.in {
+		r.in[i] = make([]float64, r.halfTaps)
+	}
+	r.offset = -r.halfTaps
+	r.received = 0
+	r.next = 0
+}
+
+// position returns absolute position of the next output sample in input samples.
+// It's calculated from index to avoid accumulation of rounding errors.
+func (r *Resampler) position() float64 {
+	return float64(r.next) * r.step
+}
+
+// resample interpolates all output samples which have enough input
+// samples and drops input samples which aren't needed any more.
+func (r *Resampler) resample() phono.Buffer {
+	// buffer without channels has nothing to interpolate.
+	if len(r.in) == 0 {
+		return phono.Buffer{}
+	}
+	result := phono.Buffer(make([][]float64, len(r.in)))
+	size := len(r.in[0])
+	for position := r.position(); position < float64(r.received); position = r.position() {
+		local := position - float64(r.offset)
+		if int(local)+r.halfTaps >= size {
+			break
+		}
+		for i := range r.in {
+			result[i] = append(result[i], r.interpolate(r.in[i], local))
+		}
+		r.next++
+	}
+	if discard := int(r.position()-float64(r.offset)) - r.halfTaps + 1; discard > 0 {
+		if discard > size {
+			discard = size
+		}
+		for i := range r.in {
+			r.in[i] = r.in[i][discard:]
+		}
+		r.offset += discard
+	}
+	for i := range result {
+		if result[i] == nil {
+			result[i] = []float64{}
+		}
+	}
+	return result
+}
+
+// interpolate returns value of signal at position.
+func (r *Resampler) interpolate(samples []float64, position float64) float64 {
+	var sum float64
+	center := int(position)
+	for k := center - r.halfTaps + 1; k <= center+r.halfTaps; k++ {
+		sum += samples[k] * r.kernel(position-float64(k))
+	}
+	return sum
+}
+
+// kernel returns value of Blackman-windowed sinc filter at distance x.
+func (r *Resampler) kernel(x float64) float64 {
+	width := float64(r.halfTaps)
+	if x <= -width || x >= width {
+		return 0
+	}
+	window := 0.42 + 0.5*math.Cos(math.Pi*x/width) + 0.08*math.Cos(2*math.Pi*x/width)
+	return r.cutoff * sinc(r.cutoff*x) * window
+}
+
+// sinc is a normalized sinc function.
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
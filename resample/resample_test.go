@@ -0,0 +1,74 @@
+package resample_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/resample"
+)
+
+func TestResample(t *testing.T) {
+	tests := []struct {
+		from phono.SampleRate
+		to   phono.SampleRate
+	}{
+		{from: 44100, to: 44100},
+		{from: 44100, to: 48000},
+		{from: 48000, to: 44100},
+		{from: 48000, to: 24000},
+	}
+	const (
+		frequency  = 441.0
+		numBuffers = 10
+		bufferSize = 512
+	)
+	for _, test := range tests {
+		r := resample.New(test.from, test.to, resample.Medium)
+		fn, err := r.Process("")
+		assert.Nil(t, err)
+
+		var result phono.Buffer
+		for i := 0; i < numBuffers; i++ {
+			b := phono.EmptyBuffer(2, bufferSize)
+			for j := range b[0] {
+				v := math.Sin(2 * math.Pi * frequency * float64(i*bufferSize+j) / float64(test.from))
+				b[0][j], b[1][j] = v, -v
+			}
+			out, err := fn(b)
+			assert.Nil(t, err)
+			assert.Equal(t, 2, len(out))
+			result = result.Append(out)
+		}
+		for {
+			out, err := r.Drain("")
+			if err == phono.ErrEOP {
+				break
+			}
+			assert.Nil(t, err)
+			result = result.Append(out)
+		}
+
+		expected := int(math.Ceil(float64(numBuffers*bufferSize) * float64(test.to) / float64(test.from)))
+		assert.Equal(t, phono.BufferSize(expected), result.Size(), "%v to %v", test.from, test.to)
+		// skip edges, where signal is interpolated with silence.
+		for j := 64; j < expected-64; j++ {
+			v := math.Sin(2 * math.Pi * frequency * float64(j) / float64(test.to))
+			assert.InDelta(t, v, result[0][j], 0.01, "%v to %v at %v", test.from, test.to, j)
+			assert.InDelta(t, -v, result[1][j], 0.01, "%v to %v at %v", test.from, test.to, j)
+		}
+	}
+}
+
+func TestResampleEmpty(t *testing.T) {
+	r := resample.New(44100, 48000, resample.Medium)
+	fn, err := r.Process("")
+	assert.Nil(t, err)
+	out, err := fn(phono.Buffer{})
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(out))
+	_, err = r.Drain("")
+	assert.Equal(t, phono.ErrEOP, err)
+}
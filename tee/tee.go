@@ -0,0 +1,174 @@
+// Package tee provides a component which splits one pipe into multiple branches.
+package tee
+
+import (
+	"sync"
+
+	"github.com/dudk/phono"
+)
+
+// Tee is a sink for input pipe and a pump for output pipes. Every output
+// receives its own copy of input buffers, so branches can modify them.
+// Outputs have a bounded queue of buffers. If any queue is full, input is
+// blocked until the slowest branch catches up. Output pump waits for input,
+// so output pipe can't be stopped while no input is flowing. Such output is
+// finished with Tee.Stop.
+type Tee struct {
+	phono.UID
+	size int
+
+	m       sync.Mutex
+	outputs map[string]*output
+}
+
+// output is a branch of tee.
+type output struct {
+	buffers chan phono.Buffer // nil buffer means the end of input.
+	done    chan struct{}     // closed when output is interrupted or stopped.
+	stop    chan struct{}     // receives when output is stopped.
+}
+
+// New creates tee. Size defines how many buffers each output can queue.
+func New(size int) *Tee {
+	return &Tee{
+		UID:     phono.NewUID(),
+		size:    size,
+		outputs: make(map[string]*output),
+	}
+}
+
+// Pump registers new output.
+func (t *Tee) Pump(outputID string) (phono.PumpFunc, error) {
+	o := &output{
+		buffers: make(chan phono.Buffer, t.size),
+		done:    make(chan struct{}),
+		stop:    make(chan struct{}, 1),
+	}
+	t.m.Lock()
+	t.outputs[outputID] = o
+	t.m.Unlock()
+	return func() (phono.Buffer, error) {
+		select {
+		case b := <-o.buffers:
+			if b == nil {
+				return nil, phono.ErrEOP
+			}
+			return b, nil
+		case <-o.stop:
+			// input doesn't wait for stopped output.
+			t.finish(outputID)
+			return nil, phono.ErrEOP
+		}
+	}, nil
+}
+
+// Stop finishes output pipe with outputID, input doesn't wait for it
+// anymore. If output isn't running, it's finished as soon as it's run
+// next time.
+func (t *Tee) Stop(outputID string) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	if o, ok := t.outputs[outputID]; ok {
+		select {
+		case o.stop <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Sink returns function which sends copies of buffers to all outputs.
+func (t *Tee) Sink(string) (phono.SinkFunc, error) {
+	return func(b phono.Buffer) error {
+		t.broadcast(b)
+		return nil
+	}, nil
+}
+
+// Flush implements pipe.Flusher. When input is done, outputs are finished.
+func (t *Tee) Flush(sourceID string) error {
+	if !t.isOutput(sourceID) {
+		t.broadcast(nil)
+	}
+	return nil
+}
+
+// Interrupt implements pipe.Interrupter. If input is interrupted, outputs
+// are finished. If output is interrupted, input doesn't wait for it anymore.
+func (t *Tee) Interrupt(sourceID string) error {
+	if !t.isOutput(sourceID) {
+		t.broadcast(nil)
+		return nil
+	}
+	t.finish(sourceID)
+	return nil
+}
+
+// finish closes done channel of output if it's not closed yet, so input
+// doesn't wait for it.
+func (t *Tee) finish(outputID string) {
+	t.m.Lock()
+	defer t.m.Unlock()
+	o := t.outputs[outputID]
+	select {
+	case <-o.done:
+	default:
+		close(o.done)
+	}
+}
+
+// Reset implements pipe.Resetter. Interrupted or stopped output is enabled
+// again and buffers left after interruption are dropped.
+func (t *Tee) Reset(sourceID string) error {
+	t.m.Lock()
+	defer t.m.Unlock()
+	o, ok := t.outputs[sourceID]
+	if !ok {
+		return nil
+	}
+	select {
+	case <-o.done:
+		o.done = make(chan struct{})
+		for len(o.buffers) > 0 {
+			<-o.buffers
+		}
+	default:
+	}
+	return nil
+}
+
+// isOutput checks if source is one of outputs.
+func (t *Tee) isOutput(sourceID string) bool {
+	t.m.Lock()
+	defer t.m.Unlock()
+	_, ok := t.outputs[sourceID]
+	return ok
+}
+
+// broadcast sends copies of buffer to all outputs. Nil buffer is sent as is.
+func (t *Tee) broadcast(b phono.Buffer) {
+	t.m.Lock()
+	outputs := make([]output, 0, len(t.outputs))
+	for _, o := range t.outputs {
+		outputs = append(outputs, *o)
+	}
+	t.m.Unlock()
+	for _, o := range outputs {
+		select {
+		case o.buffers <- copyBuffer(b):
+		case <-o.done:
+		}
+	}
+}
+
+// copyBuffer returns deep copy of buffer.
+func copyBuffer(b phono.Buffer) phono.Buffer {
+	if b == nil {
+		return nil
+	}
+	result := phono.Buffer(make([][]float64, len(b)))
+	for i := range b {
+		result[i] = make([]float64, len(b[i]))
+		copy(result[i], b[i])
+	}
+	return result
+}
@@ -0,0 +1,116 @@
+package tee_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/gain"
+	"github.com/dudk/phono/mock"
+	"github.com/dudk/phono/pipe"
+	"github.com/dudk/phono/tee"
+)
+
+func TestTee(t *testing.T) {
+	sampleRate := phono.SampleRate(44100)
+	pump := &mock.Pump{
+		UID:         phono.NewUID(),
+		Limit:       10,
+		BufferSize:  10,
+		NumChannels: 2,
+		Value:       0.5,
+	}
+	split := tee.New(1)
+	input, err := pipe.New(
+		sampleRate,
+		pipe.WithPump(pump),
+		pipe.WithSinks(split),
+	)
+	assert.Nil(t, err)
+	dry := &mock.Sink{UID: phono.NewUID()}
+	dryBranch, err := pipe.New(
+		sampleRate,
+		pipe.WithPump(split),
+		pipe.WithSinks(dry),
+	)
+	assert.Nil(t, err)
+	wet := &mock.Sink{UID: phono.NewUID()}
+	wetBranch, err := pipe.New(
+		sampleRate,
+		pipe.WithPump(split),
+		pipe.WithProcessors(gain.New(-6)),
+		pipe.WithSinks(wet),
+	)
+	assert.Nil(t, err)
+
+	// tee can be used in many runs.
+	for i := 0; i < 2; i++ {
+		dryErrc := dryBranch.Run()
+		wetErrc := wetBranch.Run()
+		inputErrc := input.Run()
+		assert.Nil(t, pipe.Wait(inputErrc))
+		assert.Nil(t, pipe.Wait(dryErrc))
+		assert.Nil(t, pipe.Wait(wetErrc))
+
+		for _, sink := range []*mock.Sink{dry, wet} {
+			messages, samples := sink.Count()
+			assert.Equal(t, int64(10), messages)
+			assert.Equal(t, int64(100), samples)
+		}
+		for i := range dry.Buffer {
+			for j := range dry.Buffer[i] {
+				assert.Equal(t, 0.5, dry.Buffer[i][j])
+				assert.InDelta(t, 0.25, wet.Buffer[i][j], 0.01)
+			}
+		}
+	}
+	_ = pipe.Wait(input.Close())
+	_ = pipe.Wait(dryBranch.Close())
+	_ = pipe.Wait(wetBranch.Close())
+}
+
+func TestStop(t *testing.T) {
+	split := tee.New(1)
+	sink := &mock.Sink{UID: phono.NewUID()}
+	branch, err := pipe.New(
+		44100,
+		pipe.WithPump(split),
+		pipe.WithSinks(sink),
+	)
+	assert.Nil(t, err)
+
+	// output is stopped while there is no input.
+	errc := branch.Run()
+	split.Stop(branch.ID())
+	done := make(chan error)
+	go func() {
+		done <- pipe.Wait(errc)
+	}()
+	select {
+	case err = <-done:
+		assert.Nil(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("output isn't stopped")
+	}
+	messages, _ := sink.Count()
+	assert.Equal(t, int64(0), messages)
+
+	// input doesn't wait for stopped output.
+	pump := &mock.Pump{
+		UID:         phono.NewUID(),
+		Limit:       5,
+		BufferSize:  10,
+		NumChannels: 1,
+	}
+	input, err := pipe.New(
+		44100,
+		pipe.WithPump(pump),
+		pipe.WithSinks(split),
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, pipe.Wait(input.Run()))
+	_ = pipe.Wait(input.Close())
+	_ = pipe.Wait(branch.Close())
+}
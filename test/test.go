@@ -26,25 +26,35 @@ var (
 
 	// List of all outputs to avoid collision.
 	Out = struct {
-		Wav1     string
-		Wav2     string
-		Track    string
-		Example2 string
-		Example3 string
-		Example4 string
-		Example5 string
-		Mixer    string
-		Mp3      string
+		Wav1      string
+		Wav2      string
+		Track     string
+		Example2  string
+		Example3  string
+		Example4  string
+		Example5  string
+		Mixer     string
+		Mp3       string
+		Flac      string
+		Fxp       string
+		Fxb       string
+		Truncated string
+		Format    string
 	}{
-		Wav1:     resolvePath(testdata + out + "wav1.wav"),
-		Wav2:     resolvePath(testdata + out + "wav2.wav"),
-		Track:    resolvePath(testdata + out + "track.wav"),
-		Example2: resolvePath(testdata + out + "example2.wav"),
-		Example3: resolvePath(testdata + out + "example3.wav"),
-		Example4: resolvePath(testdata + out + "example4.wav"),
-		Example5: resolvePath(testdata + out + "example5.wav"),
-		Mixer:    resolvePath(testdata + out + "mixer.wav"),
-		Mp3:      resolvePath(testdata + out + "mp3.mp3"),
+		Wav1:      resolvePath(testdata + out + "wav1.wav"),
+		Wav2:      resolvePath(testdata + out + "wav2.wav"),
+		Track:     resolvePath(testdata + out + "track.wav"),
+		Example2:  resolvePath(testdata + out + "example2.wav"),
+		Example3:  resolvePath(testdata + out + "example3.wav"),
+		Example4:  resolvePath(testdata + out + "example4.wav"),
+		Example5:  resolvePath(testdata + out + "example5.wav"),
+		Mixer:     resolvePath(testdata + out + "mixer.wav"),
+		Mp3:       resolvePath(testdata + out + "mp3.mp3"),
+		Flac:      resolvePath(testdata + out + "flac.flac"),
+		Fxp:       resolvePath(testdata + out + "preset.fxp"),
+		Fxb:       resolvePath(testdata + out + "preset.fxb"),
+		Truncated: resolvePath(testdata + out + "truncated.wav"),
+		Format:    resolvePath(testdata + out + "format.wav"),
 	}
 )
 
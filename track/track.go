@@ -9,6 +9,7 @@ type Track struct {
 	phono.UID
 	bs phono.BufferSize
 	phono.NumChannels
+	sum bool // overlapping clips are summed.
 
 	start   *clip
 	end     *clip
@@ -48,6 +49,14 @@ func New(bs phono.BufferSize, nc phono.NumChannels) (t *Track) {
 	return
 }
 
+// NewSum creates a new track where overlapping clips are summed up
+// instead of being replaced by the last added clip.
+func NewSum(bs phono.BufferSize, nc phono.NumChannels) *Track {
+	t := New(bs, nc)
+	t.sum = true
+	return t
+}
+
 // BufferSizeParam pushes new limit value for pump.
 func (t *Track) BufferSizeParam(bs phono.BufferSize) phono.Param {
 	return phono.Param{
@@ -66,7 +75,12 @@ func (t *Track) Pump(string) (phono.PumpFunc, error) {
 		if t.nextIndex >= t.clipsEnd() {
 			return nil, phono.ErrEOP
 		}
-		b := t.bufferAt(t.nextIndex)
+		var b phono.Buffer
+		if t.sum {
+			b = t.sumAt(t.nextIndex)
+		} else {
+			b = t.bufferAt(t.nextIndex)
+		}
 		t.nextIndex += int64(t.bs)
 		return b, nil
 	}, nil
@@ -118,6 +132,36 @@ func (t *Track) bufferAt(index int64) (result phono.Buffer) {
 	return result
 }
 
+// sumAt returns sum of all clips which sound within the buffer started at index.
+func (t *Track) sumAt(index int64) phono.Buffer {
+	result := phono.EmptyBuffer(t.NumChannels, t.bs)
+	bufferEnd := index + int64(t.bs)
+	for c := t.start; c != nil && c.At < bufferEnd; c = c.Next {
+		if c.End() <= index {
+			continue
+		}
+		// from is a position of the first clip sample within buffer.
+		from := c.At - index
+		if from < 0 {
+			from = 0
+		}
+		b := c.Slice(c.Start+index+from-c.At, int(t.bs)-int(from))
+		for nc := range b {
+			if nc >= int(t.NumChannels) {
+				break
+			}
+			size := len(b[nc])
+			if remained := int(c.End() - index - from); size > remained {
+				size = remained
+			}
+			for i := 0; i < size; i++ {
+				result[nc][int(from)+i] += b[nc][i]
+			}
+		}
+	}
+	return result
+}
+
 // clipAfter searches for a first clip after passed index.
 // returns start position of clip and index in clip.
 func (t *Track) clipAfter(index int64) *clip {
@@ -136,6 +180,16 @@ func (t *Track) clipsEnd() int64 {
 	if t.end == nil {
 		return -1
 	}
+	if t.sum {
+		// clips can overlap, so the last clip doesn't always end last.
+		end := int64(-1)
+		for c := t.start; c != nil; c = c.Next {
+			if c.End() > end {
+				end = c.End()
+			}
+		}
+		return end
+	}
 	return t.end.At + int64(t.end.Len)
 }
 
@@ -170,7 +224,9 @@ func (t *Track) AddClip(at int64, f phono.Clip) {
 	c.Next = next
 	c.Prev = prev
 
-	t.resolveOverlaps(c)
+	if !t.sum {
+		t.resolveOverlaps(c)
+	}
 }
 
 // resolveOverlaps resolves overlaps
@@ -206,3 +206,21 @@ func TestSliceOverlaps(t *testing.T) {
 	}
 
 }
+
+func TestSumOverlaps(t *testing.T) {
+	sink := &mock.Sink{UID: phono.NewUID()}
+	track := track.NewSum(phono.BufferSize(3), buffer1.NumChannels())
+	track.AddClip(2, buffer1.Clip(3, 4))
+	track.AddClip(4, buffer2.Clip(5, 2))
+	track.AddClip(9, buffer2.Clip(0, 1))
+
+	p, err := pipe.New(
+		sampleRate,
+		pipe.WithPump(track),
+		pipe.WithSinks(sink),
+	)
+	assert.Nil(t, err)
+	err = pipe.Wait(p.Run())
+	assert.Nil(t, err)
+	assert.Equal(t, []float64{0, 0, 1, 1, 3, 3, 0, 0, 0, 2, 0, 0}, sink.Buffer[0])
+}
@@ -0,0 +1,156 @@
+// Package trim provides processor which removes leading and trailing silence.
+package trim
+
+import (
+	"math"
+	"time"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/gain"
+)
+
+// Trim drops silence in the beginning and in the end of the signal.
+// Sample is silent if it's below threshold in all channels. Silence
+// shorter than minimum duration isn't trimmed. Silence in the middle
+// is kept until the next signal arrives, because it could be the
+// trailing one. Downstream components receive only trimmed signal,
+// so their positions are relative to trimmed output.
+type Trim struct {
+	phono.UID
+	threshold  float64 // linear level.
+	minSamples int64
+
+	started  bool         // signal above threshold is received.
+	silence  phono.Buffer // silent samples which aren't sent yet.
+	silent   int64        // number of silent samples received in a row.
+	leading  int64
+	trailing int64
+}
+
+// New creates new trim processor. Threshold is defined in dBFS.
+func New(sampleRate phono.SampleRate, threshold float64, minDuration time.Duration) *Trim {
+	return &Trim{
+		UID:        phono.NewUID(),
+		threshold:  gain.FromDecibels(threshold),
+		minSamples: int64(float64(sampleRate) * minDuration.Seconds()),
+	}
+}
+
+// Process implements phono.Processor. It returns nil if all
+// received samples could belong to silence.
+func (t *Trim) Process(string) (phono.ProcessFunc, error) {
+	return func(b phono.Buffer) (phono.Buffer, error) {
+		size := int(b.Size())
+		if size == 0 {
+			return nil, nil
+		}
+		var result phono.Buffer
+		start := 0
+		if !t.started {
+			first := t.firstSignal(b)
+			if first < 0 {
+				t.keepLeading(b)
+				return nil, nil
+			}
+			t.started = true
+			if t.silent+int64(first) < t.minSamples {
+				result = t.silence
+			} else {
+				t.leading = t.silent + int64(first)
+				start = first
+			}
+			t.silence, t.silent = nil, 0
+		}
+		last := t.lastSignal(b)
+		if last < start {
+			t.silence = join(t.silence, b.Slice(int64(start), size-start))
+			t.silent += int64(size - start)
+			return result, nil
+		}
+		result = join(join(result, t.silence), b.Slice(int64(start), last+1-start))
+		t.silence, t.silent = b.Slice(int64(last+1), size-last-1), int64(size-last-1)
+		return result, nil
+	}, nil
+}
+
+// Drain implements pipe.Drainer. It returns the trailing silence if it's
+// shorter than minimum duration.
+func (t *Trim) Drain(string) (phono.Buffer, error) {
+	result, silent := t.silence, t.silent
+	t.silence, t.silent = nil, 0
+	if silent >= t.minSamples {
+		if t.started {
+			t.trailing = silent
+		} else {
+			t.leading = silent
+		}
+		return nil, phono.ErrEOP
+	}
+	if result == nil {
+		return nil, phono.ErrEOP
+	}
+	return result, nil
+}
+
+// Reset implements pipe.Resetter.
+func (t *Trim) Reset(string) error {
+	t.started = false
+	t.silence, t.silent = nil, 0
+	t.leading, t.trailing = 0, 0
+	return nil
+}
+
+// Trimmed returns number of samples trimmed in the beginning and in the end.
+// Trailing silence is known only when input is done.
+func (t *Trim) Trimmed() (leading, trailing int64) {
+	return t.leading, t.trailing
+}
+
+// keepLeading collects leading silence. Once it's long enough
+// to be trimmed, samples are counted, but not kept.
+func (t *Trim) keepLeading(b phono.Buffer) {
+	t.silent += int64(b.Size())
+	if t.silent >= t.minSamples {
+		t.silence = nil
+		return
+	}
+	t.silence = join(t.silence, b.Slice(0, int(b.Size())))
+}
+
+// firstSignal returns index of the first sample above threshold or -1 if there is no such sample.
+func (t *Trim) firstSignal(b phono.Buffer) int {
+	for j := 0; j < int(b.Size()); j++ {
+		if t.isSignal(b, j) {
+			return j
+		}
+	}
+	return -1
+}
+
+// lastSignal returns index of the last sample above threshold or -1 if there is no such sample.
+func (t *Trim) lastSignal(b phono.Buffer) int {
+	for j := int(b.Size()) - 1; j >= 0; j-- {
+		if t.isSignal(b, j) {
+			return j
+		}
+	}
+	return -1
+}
+
+// isSignal checks if sample is above threshold in any channel.
+func (t *Trim) isSignal(b phono.Buffer, j int) bool {
+	for i := range b {
+		if math.Abs(b[i][j]) >= t.threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// join appends samples of source to buffer. Any of buffers can be nil.
+func join(b, source phono.Buffer) phono.Buffer {
+	if source == nil {
+		return b
+	}
+	return b.Append(source)
+}
@@ -0,0 +1,95 @@
+package trim_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/mock"
+	"github.com/dudk/phono/pipe"
+	"github.com/dudk/phono/trim"
+)
+
+func TestTrim(t *testing.T) {
+	tests := []struct {
+		in       []float64
+		expected []float64
+		leading  int64
+		trailing int64
+	}{
+		{
+			in:       []float64{0, 0, 0, 0, 0.5, 0, 0.5, 0.01, 0, 0, 0},
+			expected: []float64{0.5, 0, 0.5},
+			leading:  4,
+			trailing: 4,
+		},
+		// silence shorter than minimum duration is kept.
+		{
+			in:       []float64{0, 0, -0.5, 0, 0},
+			expected: []float64{0, 0, -0.5, 0, 0},
+		},
+		{
+			in:       []float64{0.5, 0, 0, 0, 0, 0, 0, 0.5, 0, 0, 0},
+			expected: []float64{0.5, 0, 0, 0, 0, 0, 0, 0.5},
+			trailing: 3,
+		},
+		{
+			in:      []float64{0, 0, 0, 0, 0},
+			leading: 5,
+		},
+	}
+	// 3 samples of minimum duration.
+	tr := trim.New(1000, -20, 3*time.Millisecond)
+	fn, err := tr.Process("")
+	assert.Nil(t, err)
+	for _, test := range tests {
+		assert.Nil(t, tr.Reset(""))
+		var result []float64
+		for i := 0; i < len(test.in); i += 3 {
+			end := i + 3
+			if end > len(test.in) {
+				end = len(test.in)
+			}
+			out, err := fn(phono.Buffer{test.in[i:end]})
+			assert.Nil(t, err)
+			if out != nil {
+				result = append(result, out[0]...)
+			}
+		}
+		for {
+			out, err := tr.Drain("")
+			if err == phono.ErrEOP {
+				break
+			}
+			assert.Nil(t, err)
+			result = append(result, out[0]...)
+		}
+		assert.Equal(t, test.expected, result)
+		leading, trailing := tr.Trimmed()
+		assert.Equal(t, test.leading, leading)
+		assert.Equal(t, test.trailing, trailing)
+	}
+}
+
+func TestTrimPipe(t *testing.T) {
+	pump := &mock.Pump{
+		UID:         phono.NewUID(),
+		Limit:       10,
+		BufferSize:  10,
+		NumChannels: 2,
+		Value:       0.5,
+	}
+	sink := &mock.Sink{UID: phono.NewUID()}
+	p, err := pipe.New(
+		44100,
+		pipe.WithPump(pump),
+		pipe.WithProcessors(trim.New(44100, -60, time.Millisecond)),
+		pipe.WithSinks(sink),
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, pipe.Wait(p.Run()))
+	_, samples := sink.Count()
+	assert.Equal(t, int64(100), samples)
+}
@@ -0,0 +1,105 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+/*
+#include <stdlib.h>
+#include "aeffectx.h"
+
+// newSpeakerArrangement allocates VstSpeakerArrangement for n speakers. Structure
+// declares space only for eight speakers, so the rest is allocated in the end.
+static VstSpeakerArrangement *newSpeakerArrangement(int n) {
+	size_t size = sizeof(VstSpeakerArrangement);
+	if (n > 8) {
+		size += (n - 8) * sizeof(VstSpeakerProperties);
+	}
+	VstSpeakerArrangement *arrangement = calloc(1, size);
+	arrangement->numChannels = n;
+	return arrangement;
+}
+
+static void setSpeaker(VstSpeakerArrangement *arrangement, int i, VstInt32 type) {
+	arrangement->speakers[i].type = type;
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/dudk/vst2"
+)
+
+// speakerLayout describes arrangement type and speakers of common layouts.
+type speakerLayout struct {
+	arrangement C.VstInt32
+	speakers    []C.VstInt32
+}
+
+// speakerLayouts maps number of channels to layout.
+var speakerLayouts = map[int]speakerLayout{
+	1: {
+		arrangement: C.kSpeakerArrMono,
+		speakers:    []C.VstInt32{C.kSpeakerM},
+	},
+	2: {
+		arrangement: C.kSpeakerArrStereo,
+		speakers:    []C.VstInt32{C.kSpeakerL, C.kSpeakerR},
+	},
+	6: {
+		arrangement: C.kSpeakerArr51,
+		speakers:    []C.VstInt32{C.kSpeakerL, C.kSpeakerR, C.kSpeakerC, C.kSpeakerLfe, C.kSpeakerLs, C.kSpeakerRs},
+	},
+	8: {
+		arrangement: C.kSpeakerArr71Music,
+		speakers:    []C.VstInt32{C.kSpeakerL, C.kSpeakerR, C.kSpeakerC, C.kSpeakerLfe, C.kSpeakerLs, C.kSpeakerRs, C.kSpeakerSl, C.kSpeakerSr},
+	},
+}
+
+// newSpeakerArrangement allocates arrangement for number of channels. Mono, stereo, 5.1
+// and 7.1 layouts have speakers defined, other layouts are reported as user-defined.
+func newSpeakerArrangement(numChannels int) *C.VstSpeakerArrangement {
+	arrangement := C.newSpeakerArrangement(C.int(numChannels))
+	layout, ok := speakerLayouts[numChannels]
+	switch {
+	case ok:
+		arrangement._type = layout.arrangement
+		for i, speaker := range layout.speakers {
+			C.setSpeaker(arrangement, C.int(i), speaker)
+		}
+		return arrangement
+	case numChannels == 0:
+		arrangement._type = C.kSpeakerArrEmpty
+	default:
+		arrangement._type = C.kSpeakerArrUserDefined
+	}
+	for i := 0; i < numChannels; i++ {
+		C.setSpeaker(arrangement, C.int(i), C.kSpeakerUndefined)
+	}
+	return arrangement
+}
+
+// SetSpeakerArrangement sends input and output speaker arrangements for
+// number of channels to plugin. It returns false if plugin doesn't accept
+// arrangement. In this case plugin keeps its default layout, so buffers
+// still can be processed with plugin's inputs and outputs.
+func (p *Plugin) SetSpeakerArrangement(numInputs, numOutputs int) bool {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.freeSpeakerArrangement()
+	p.speakers[0] = newSpeakerArrangement(numInputs)
+	p.speakers[1] = newSpeakerArrangement(numOutputs)
+	in := int64(uintptr(unsafe.Pointer(p.speakers[0])))
+	return p.dispatch(vst2.EffSetSpeakerArrangement, 0, in, unsafe.Pointer(p.speakers[1]), 0) != 0
+}
+
+// freeSpeakerArrangement releases arrangements sent to plugin. Caller must hold the lock.
+func (p *Plugin) freeSpeakerArrangement() {
+	for i, arrangement := range p.speakers {
+		if arrangement != nil {
+			C.free(unsafe.Pointer(arrangement))
+			p.speakers[i] = nil
+		}
+	}
+}
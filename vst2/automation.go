@@ -0,0 +1,46 @@
+package vst2
+
+import (
+	"sort"
+	"sync"
+)
+
+// AutomationPoint is a parameter change scheduled at position.
+type AutomationPoint struct {
+	Position int64   // position in samples from the start of run.
+	Index    int     // index of parameter.
+	Value    float32 // normalized value of parameter.
+}
+
+// automation holds points until they're applied to plugin.
+type automation struct {
+	m      sync.Mutex
+	points []AutomationPoint
+}
+
+// push adds points to automation.
+func (a *automation) push(points []AutomationPoint) {
+	a.m.Lock()
+	a.points = append(a.points, points...)
+	a.m.Unlock()
+}
+
+// pop returns sorted points with position before end. Points in the
+// past are returned as well, so they're applied as soon as possible.
+func (a *automation) pop(end int64) []AutomationPoint {
+	a.m.Lock()
+	defer a.m.Unlock()
+	if len(a.points) == 0 {
+		return nil
+	}
+	sort.SliceStable(a.points, func(i, j int) bool {
+		return a.points[i].Position < a.points[j].Position
+	})
+	i := sort.Search(len(a.points), func(i int) bool {
+		return a.points[i].Position >= end
+	})
+	result := make([]AutomationPoint, i)
+	copy(result, a.points[:i])
+	a.points = a.points[i:]
+	return result
+}
@@ -0,0 +1,30 @@
+package vst2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAutomation(t *testing.T) {
+	var a automation
+	a.push([]AutomationPoint{
+		{Position: 700, Index: 0, Value: 0.7},
+		{Position: 10, Index: 1, Value: 0.1},
+		{Position: 512, Index: 0, Value: 0.5},
+	})
+
+	points := a.pop(512)
+	assert.Equal(t, 1, len(points))
+	assert.Equal(t, int64(10), points[0].Position)
+
+	// late points are returned too.
+	a.push([]AutomationPoint{{Position: 0, Index: 1, Value: 0}})
+	points = a.pop(1024)
+	assert.Equal(t, 3, len(points))
+	assert.Equal(t, int64(0), points[0].Position)
+	assert.Equal(t, int64(512), points[1].Position)
+	assert.Equal(t, int64(700), points[2].Position)
+
+	assert.Nil(t, a.pop(2048))
+}
@@ -0,0 +1,261 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/flac"
+	"github.com/dudk/phono/pipe"
+	"github.com/dudk/phono/wav"
+)
+
+// Batch renders every wav and flac file of directory through plugin. Output
+// files have the same name and format as input ones.
+type Batch struct {
+	plugin      *Plugin
+	bufferSize  phono.BufferSize
+	concurrency int
+	flushTail   bool
+}
+
+// flacLevel is a compression level of rendered flac files.
+const flacLevel = 5
+
+// NewBatch creates new batch for plugin. Plugin's current settings are
+// applied to every file.
+func NewBatch(plugin *Plugin, bufferSize phono.BufferSize) *Batch {
+	return &Batch{
+		plugin:      plugin,
+		bufferSize:  bufferSize,
+		concurrency: 1,
+	}
+}
+
+// SetConcurrency sets number of files rendered in parallel. Every file
+// is rendered by its own plugin instance, so additional instances are
+// opened from plugin's path. Values below 1 are treated as 1.
+func (b *Batch) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	b.concurrency = n
+}
+
+// SetFlushTail enables processing of plugin's tail, so output files can be
+// longer than input ones. See Processor.SetFlushTail.
+func (b *Batch) SetFlushTail(flushTail bool) {
+	b.flushTail = flushTail
+}
+
+// Render processes files of input directory and writes results into output
+// directory. Subdirectories aren't processed. Files which failed to render
+// don't stop the batch, their errors are returned as BatchErrors.
+func (b *Batch) Render(in, out string) error {
+	files, err := ioutil.ReadDir(in)
+	if err != nil {
+		return fmt.Errorf("Failed to read input directory %v: %v", in, err)
+	}
+	same, err := samePath(in, out)
+	if err != nil {
+		return err
+	}
+	if same {
+		return fmt.Errorf("Output directory %v must differ from input directory", out)
+	}
+	if err := os.MkdirAll(out, 0755); err != nil {
+		return fmt.Errorf("Failed to create output directory %v: %v", out, err)
+	}
+	var paths []string
+	for _, fi := range files {
+		if !fi.IsDir() && isAudioFile(fi.Name()) {
+			paths = append(paths, fi.Name())
+		}
+	}
+
+	plugins, err := b.instances(len(paths))
+	if err != nil {
+		return err
+	}
+	settings := b.plugin.settings()
+	queue := make(chan string)
+	errs := BatchErrors{}
+	var (
+		m  sync.Mutex
+		wg sync.WaitGroup
+	)
+	for _, plugin := range plugins {
+		wg.Add(1)
+		go func(plugin *Plugin) {
+			defer wg.Done()
+			for name := range queue {
+				// every file starts with the same settings.
+				settings.apply(plugin)
+				if err := b.render(plugin, filepath.Join(in, name), filepath.Join(out, name)); err != nil {
+					m.Lock()
+					errs[filepath.Join(in, name)] = err
+					m.Unlock()
+				}
+			}
+		}(plugin)
+	}
+	for _, name := range paths {
+		queue <- name
+	}
+	close(queue)
+	wg.Wait()
+	for _, plugin := range plugins[1:] {
+		plugin.Close()
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// instances returns plugin and its additional instances to render files in parallel.
+func (b *Batch) instances(files int) ([]*Plugin, error) {
+	n := b.concurrency
+	if n > files {
+		n = files
+	}
+	plugins := []*Plugin{b.plugin}
+	for i := 1; i < n; i++ {
+		plugin, err := b.openInstance()
+		if err != nil {
+			for _, p := range plugins[1:] {
+				p.Close()
+			}
+			return nil, fmt.Errorf("Failed to open plugin instance: %w", err)
+		}
+		plugins = append(plugins, plugin)
+	}
+	return plugins, nil
+}
+
+// openInstance creates another instance of batch plugin. Sub-plugin of shell
+// plugin is opened with the same shell id.
+func (b *Batch) openInstance() (*Plugin, error) {
+	if id := b.plugin.ShellID(); id != 0 {
+		return OpenShell(b.plugin.Info().Path, id)
+	}
+	return Open(b.plugin.Info().Path)
+}
+
+// render processes a single file.
+func (b *Batch) render(plugin *Plugin, in, out string) error {
+	f, err := b.open(in, out)
+	if err != nil {
+		return err
+	}
+	processor := NewProcessor(plugin, b.bufferSize, f.sampleRate, f.numChannels)
+	processor.SetOffline(true)
+	processor.SetFlushTail(b.flushTail)
+	p, err := pipe.New(
+		f.sampleRate,
+		pipe.WithPump(f.pump),
+		pipe.WithProcessors(processor),
+		pipe.WithSinks(f.sink),
+	)
+	if err != nil {
+		return err
+	}
+	err = pipe.Wait(p.Run())
+	if closeErr := pipe.Wait(p.Close()); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// batchFile contains pump of input file and sink of output file.
+type batchFile struct {
+	pump        phono.Pump
+	sink        phono.Sink
+	sampleRate  phono.SampleRate
+	numChannels phono.NumChannels
+}
+
+// open creates pump of input file and sink of output file with the same format.
+func (b *Batch) open(in, out string) (batchFile, error) {
+	if strings.EqualFold(filepath.Ext(in), ".flac") {
+		pump, err := flac.NewPump(in, b.bufferSize)
+		if err != nil {
+			return batchFile{}, err
+		}
+		sink, err := flac.NewSink(out, pump.SampleRate(), pump.NumChannels(), pump.BitDepth(), flacLevel)
+		if err != nil {
+			pump.Flush("")
+			return batchFile{}, err
+		}
+		return batchFile{pump: pump, sink: sink, sampleRate: pump.SampleRate(), numChannels: pump.NumChannels()}, nil
+	}
+	pump, err := wav.NewPump(in, b.bufferSize)
+	if err != nil {
+		return batchFile{}, err
+	}
+	sink, err := wav.NewSink(out, pump.WavSampleRate(), pump.WavNumChannels(), pump.WavBitDepth(), pump.WavAudioFormat())
+	if err != nil {
+		pump.Flush("")
+		return batchFile{}, err
+	}
+	return batchFile{pump: pump, sink: sink, sampleRate: pump.WavSampleRate(), numChannels: pump.WavNumChannels()}, nil
+}
+
+// isAudioFile checks if file can be rendered by batch.
+func isAudioFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".wav", ".flac":
+		return true
+	}
+	return false
+}
+
+// pluginSettings is a snapshot of plugin's state or parameters.
+type pluginSettings struct {
+	state  []byte
+	params []float32
+}
+
+// settings returns current settings of plugin. State is used if plugin
+// stores it in chunks, otherwise values of parameters are kept.
+func (p *Plugin) settings() pluginSettings {
+	if state, err := p.GetState(); err == nil {
+		return pluginSettings{state: state}
+	}
+	params := make([]float32, p.NumParameters())
+	for i := range params {
+		params[i] = p.GetParameter(i)
+	}
+	return pluginSettings{params: params}
+}
+
+// apply restores settings of plugin.
+func (s pluginSettings) apply(p *Plugin) {
+	if s.state != nil {
+		p.SetState(s.state)
+		return
+	}
+	for i, v := range s.params {
+		p.SetParameter(i, v)
+	}
+}
+
+// samePath checks if paths point to the same location.
+func samePath(a, b string) (bool, error) {
+	absA, err := filepath.Abs(a)
+	if err != nil {
+		return false, err
+	}
+	absB, err := filepath.Abs(b)
+	if err != nil {
+		return false, err
+	}
+	return absA == absB, nil
+}
@@ -0,0 +1,211 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+/*
+#include <stdlib.h>
+#include <string.h>
+#include "aeffectx.h"
+
+// process field is deprecated in VST 2.4 and has different name if deprecated fields are forced.
+#ifndef DECLARE_VST_DEPRECATED
+#define DECLARE_VST_DEPRECATED(identifier) identifier
+#endif
+
+static void processAccumulating(AEffect *effect, void **inputs, void **outputs, int sampleFrames) {
+	effect->DECLARE_VST_DEPRECATED(process)(effect, (float **)inputs, (float **)outputs, sampleFrames);
+}
+
+static void processFloat(AEffect *effect, void **inputs, void **outputs, int sampleFrames) {
+	effect->processReplacing(effect, (float **)inputs, (float **)outputs, sampleFrames);
+}
+
+static void processDouble(AEffect *effect, void **inputs, void **outputs, int sampleFrames) {
+	effect->processDoubleReplacing(effect, (double **)inputs, (double **)outputs, sampleFrames);
+}
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/dudk/phono"
+)
+
+// maxSamples limits length of array types used to access C buffers.
+const maxSamples = 1 << 30
+
+// processBuffers holds C memory which is passed to plugin processing functions.
+// It's allocated lazily and reused until number of inputs, outputs, buffer
+// size or sample type changes.
+type processBuffers struct {
+	numInputs  int
+	numOutputs int
+	size       int
+	float32    bool
+	in         []unsafe.Pointer // per-channel input C arrays.
+	out        []unsafe.Pointer // per-channel output C arrays.
+}
+
+// resize reallocates buffers if dimensions or sample type differ from current.
+func (b *processBuffers) resize(numInputs, numOutputs, size int, float32 bool) {
+	if b.numInputs == numInputs && b.numOutputs == numOutputs && b.size == size && b.float32 == float32 {
+		return
+	}
+	b.free()
+	sampleSize := C.sizeof_double
+	if float32 {
+		sampleSize = C.sizeof_float
+	}
+	b.in = make([]unsafe.Pointer, numInputs)
+	b.out = make([]unsafe.Pointer, numOutputs)
+	for i := range b.in {
+		b.in[i] = C.calloc(C.size_t(size), C.size_t(sampleSize))
+	}
+	for i := range b.out {
+		b.out[i] = C.calloc(C.size_t(size), C.size_t(sampleSize))
+	}
+	b.numInputs, b.numOutputs, b.size, b.float32 = numInputs, numOutputs, size, float32
+}
+
+// write copies samples into input buffers. Channels shorter than buffer
+// size and inputs without channel are padded with silence. Channels
+// beyond plugin's inputs are ignored.
+func (b *processBuffers) write(samples [][]float64) {
+	for i := range b.in {
+		var channel []float64
+		if i < len(samples) {
+			channel = samples[i]
+		}
+		if b.float32 {
+			in := (*[maxSamples]C.float)(b.in[i])[:b.size:b.size]
+			for j := range in {
+				in[j] = 0
+				if j < len(channel) {
+					in[j] = C.float(channel[j])
+				}
+			}
+		} else {
+			in := (*[maxSamples]C.double)(b.in[i])[:b.size:b.size]
+			for j := range in {
+				in[j] = 0
+				if j < len(channel) {
+					in[j] = C.double(channel[j])
+				}
+			}
+		}
+	}
+}
+
+// read returns a copy of the first n samples of output buffers. New buffer is
+// taken from pool every time, because it's sent further down the pipe while
+// plugin processes next one.
+func (b *processBuffers) read(n int) [][]float64 {
+	result := phono.GetBuffer(phono.NumChannels(b.numOutputs), phono.BufferSize(n))
+	for i := range b.out {
+		if b.float32 {
+			out := (*[maxSamples]C.float)(b.out[i])[:n:n]
+			for j, v := range out {
+				result[i][j] = float64(v)
+			}
+		} else {
+			out := (*[maxSamples]C.double)(b.out[i])[:n:n]
+			for j, v := range out {
+				result[i][j] = float64(v)
+			}
+		}
+	}
+	return result
+}
+
+// writeFloat32 copies float32 samples into input buffers. Samples are copied
+// as is if plugin processes float32. Channels are padded the same way as in write.
+func (b *processBuffers) writeFloat32(samples [][]float32) {
+	for i := range b.in {
+		var channel []float32
+		if i < len(samples) {
+			channel = samples[i]
+		}
+		if b.float32 {
+			in := (*[maxSamples]float32)(b.in[i])[:b.size:b.size]
+			n := copy(in, channel)
+			for j := n; j < len(in); j++ {
+				in[j] = 0
+			}
+		} else {
+			in := (*[maxSamples]C.double)(b.in[i])[:b.size:b.size]
+			for j := range in {
+				in[j] = 0
+				if j < len(channel) {
+					in[j] = C.double(channel[j])
+				}
+			}
+		}
+	}
+}
+
+// readFloat32 returns a copy of the first n samples of output buffers as float32.
+func (b *processBuffers) readFloat32(n int) [][]float32 {
+	data := make([]float32, b.numOutputs*n)
+	result := make([][]float32, b.numOutputs)
+	for i := range b.out {
+		result[i] = data[i*n : (i+1)*n : (i+1)*n]
+		if b.float32 {
+			copy(result[i], (*[maxSamples]float32)(b.out[i])[:n:n])
+		} else {
+			out := (*[maxSamples]C.double)(b.out[i])[:n:n]
+			for j, v := range out {
+				result[i][j] = float32(v)
+			}
+		}
+	}
+	return result
+}
+
+// process calls plugin's replacing function with current buffers.
+// Instruments may have no inputs, so nil array is passed to them.
+// Legacy plugins which can't replace output add to it, so output
+// buffers are cleared before they're called.
+func (b *processBuffers) process(e *C.AEffect) {
+	if b.numOutputs == 0 {
+		return
+	}
+	var in *unsafe.Pointer
+	if b.numInputs > 0 {
+		in = &b.in[0]
+	}
+	if b.float32 && e.flags&C.effFlagsCanReplacing == 0 {
+		b.clearOutputs()
+		C.processAccumulating(e, in, &b.out[0], C.int(b.size))
+		return
+	}
+	if b.float32 {
+		C.processFloat(e, in, &b.out[0], C.int(b.size))
+	} else {
+		C.processDouble(e, in, &b.out[0], C.int(b.size))
+	}
+}
+
+// clearOutputs fills output buffers with silence.
+func (b *processBuffers) clearOutputs() {
+	sampleSize := C.sizeof_double
+	if b.float32 {
+		sampleSize = C.sizeof_float
+	}
+	for i := range b.out {
+		C.memset(b.out[i], 0, C.size_t(b.size*sampleSize))
+	}
+}
+
+// free releases C memory.
+func (b *processBuffers) free() {
+	for i := range b.in {
+		C.free(b.in[i])
+	}
+	for i := range b.out {
+		C.free(b.out[i])
+	}
+	b.in, b.out = nil, nil
+	b.numInputs, b.numOutputs, b.size = 0, 0, 0
+}
@@ -0,0 +1,119 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+import (
+	"github.com/dudk/phono"
+)
+
+// Chain processes buffers with a sequence of plugins in a single pipe stage.
+type Chain struct {
+	phono.UID
+	processors []*Processor
+	fns        []phono.ProcessFunc
+	drained    int // number of processors with drained tail.
+}
+
+// NewChain creates new chain of plugins. Buffers are processed in the order of plugins.
+func NewChain(plugins []*Plugin, bufferSize phono.BufferSize, sampleRate phono.SampleRate, numChannels phono.NumChannels) *Chain {
+	processors := make([]*Processor, len(plugins))
+	for i := range plugins {
+		processors[i] = NewProcessor(plugins[i], bufferSize, sampleRate, numChannels)
+	}
+	return &Chain{
+		UID:        phono.NewUID(),
+		processors: processors,
+	}
+}
+
+// Process returns processor function which sends buffer through all plugins.
+func (c *Chain) Process(sourceID string) (phono.ProcessFunc, error) {
+	c.fns = make([]phono.ProcessFunc, len(c.processors))
+	for i, p := range c.processors {
+		fn, err := p.Process(sourceID)
+		if err != nil {
+			return nil, err
+		}
+		c.fns[i] = fn
+	}
+	return func(b phono.Buffer) (phono.Buffer, error) {
+		return c.processFrom(0, b)
+	}, nil
+}
+
+// processFrom sends buffer through plugins starting from index.
+func (c *Chain) processFrom(index int, b phono.Buffer) (phono.Buffer, error) {
+	var err error
+	for _, fn := range c.fns[index:] {
+		if b, err = fn(b); err != nil {
+			return nil, err
+		}
+	}
+	return b, nil
+}
+
+// Processor returns processor of plugin with index. It provides
+// access to plugin's parameters, bypass and other settings.
+func (c *Chain) Processor(index int) *Processor {
+	return c.processors[index]
+}
+
+// SetBypass enables or disables bypass of plugin with index.
+func (c *Chain) SetBypass(index int, bypass bool) {
+	c.processors[index].SetBypass(bypass)
+}
+
+// InitialDelay returns total latency of all plugins in samples.
+func (c *Chain) InitialDelay() int {
+	delay := 0
+	for _, p := range c.processors {
+		delay += p.InitialDelay()
+	}
+	return delay
+}
+
+// SetFlushTail enables processing of plugins' tails after input is done.
+func (c *Chain) SetFlushTail(flushTail bool) {
+	for _, p := range c.processors {
+		p.SetFlushTail(flushTail)
+	}
+}
+
+// Drain implements pipe.Drainer. Tail of every plugin is sent through the rest of chain.
+func (c *Chain) Drain(sourceID string) (phono.Buffer, error) {
+	for c.drained < len(c.processors) {
+		b, err := c.processors[c.drained].Drain(sourceID)
+		if err == phono.ErrEOP {
+			c.drained++
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		return c.processFrom(c.drained+1, b)
+	}
+	c.drained = 0
+	return nil, phono.ErrEOP
+}
+
+// Reset implements pipe.Resetter.
+func (c *Chain) Reset(sourceID string) error {
+	c.drained = 0
+	for _, p := range c.processors {
+		if err := p.Reset(sourceID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Flush implements pipe.Flusher.
+func (c *Chain) Flush(sourceID string) error {
+	for _, p := range c.processors {
+		if err := p.Flush(sourceID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,61 @@
+package vst2
+
+import (
+	"github.com/dudk/phono"
+)
+
+// declick removes discontinuity of output after plugin is reconfigured or
+// bypass is toggled. Output ramps from the last sample sent before the
+// change to the new signal, so there is no step between buffers.
+type declick struct {
+	from []float64 // last samples before the change.
+	last []float64 // last samples of previous buffer.
+	pos  int       // position in ramp.
+	size int       // length of ramp, 0 if ramp isn't active.
+}
+
+// start begins ramp of size samples from the last sent samples.
+func (d *declick) start(size int) {
+	if size <= 0 || len(d.last) == 0 {
+		return
+	}
+	d.from = append(d.from[:0], d.last...)
+	d.pos, d.size = 0, size
+}
+
+// apply ramps buffer in place and keeps its last samples. Channels
+// which didn't exist before the change start from silence.
+func (d *declick) apply(b phono.Buffer) {
+	size := int(b.Size())
+	if d.size > 0 {
+		n := d.size - d.pos
+		if n > size {
+			n = size
+		}
+		for i := range b {
+			var from float64
+			if i < len(d.from) {
+				from = d.from[i]
+			}
+			for j := 0; j < n; j++ {
+				gain := float64(d.pos+j) / float64(d.size)
+				b[i][j] = from*(1-gain) + b[i][j]*gain
+			}
+		}
+		if d.pos += n; d.pos >= d.size {
+			d.size = 0
+		}
+	}
+	if size > 0 {
+		d.last = d.last[:0]
+		for i := range b {
+			d.last = append(d.last, b[i][size-1])
+		}
+	}
+}
+
+// reset drops kept samples and stops ramp.
+func (d *declick) reset() {
+	d.from, d.last = d.from[:0], d.last[:0]
+	d.pos, d.size = 0, 0
+}
@@ -0,0 +1,37 @@
+package vst2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+)
+
+func TestDeclick(t *testing.T) {
+	var d declick
+	// ramp without previous output is ignored.
+	d.start(4)
+	b := phono.Buffer{[]float64{1, 1}}
+	d.apply(b)
+	assert.Equal(t, phono.Buffer{[]float64{1, 1}}, b)
+
+	// ramp continues over buffers, new channel starts from silence.
+	d.start(4)
+	b = phono.Buffer{[]float64{0, 0, 0}, []float64{1, 1, 1}}
+	d.apply(b)
+	assert.Equal(t, phono.Buffer{[]float64{1, 0.75, 0.5}, []float64{0, 0.25, 0.5}}, b)
+	b = phono.Buffer{[]float64{0, 0}, []float64{1, 1}}
+	d.apply(b)
+	assert.Equal(t, phono.Buffer{[]float64{0.25, 0}, []float64{0.75, 1}}, b)
+
+	b = phono.Buffer{[]float64{0.5}, []float64{0.5}}
+	d.apply(b)
+	assert.Equal(t, phono.Buffer{[]float64{0.5}, []float64{0.5}}, b)
+
+	d.reset()
+	d.start(4)
+	b = phono.Buffer{[]float64{0}}
+	d.apply(b)
+	assert.Equal(t, phono.Buffer{[]float64{0}}, b)
+}
@@ -0,0 +1,95 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+// #include "aeffectx.h"
+import "C"
+
+import (
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"github.com/dudk/vst2"
+)
+
+// editorIdleInterval is an interval between idle calls while editor is open.
+const editorIdleInterval = 40 * time.Millisecond
+
+// OpenEditor opens plugin's editor in parent window. Parent is a platform-specific
+// window handle: NSView on macOS and HWND on Windows. While editor is open,
+// plugin receives idle calls, so it can redraw its interface.
+func (p *Plugin) OpenEditor(parent unsafe.Pointer) error {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if !p.hasFlag(C.effFlagsHasEditor) {
+		return ErrNoEditor
+	}
+	if parent == nil {
+		return ErrNoWindow
+	}
+	if p.editorDone != nil {
+		return nil
+	}
+	p.dispatch(vst2.EffEditOpen, 0, 0, parent, 0)
+	p.editorDone = make(chan struct{})
+	atomic.StoreInt32(&p.editorOpen, 1)
+	go p.idleEditor(p.editorDone)
+	return nil
+}
+
+// EditorOpen returns true if plugin's editor is open. It doesn't lock
+// the plugin, so it's safe to call from host callback.
+func (p *Plugin) EditorOpen() bool {
+	return atomic.LoadInt32(&p.editorOpen) == 1
+}
+
+// CloseEditor closes plugin's editor.
+func (p *Plugin) CloseEditor() {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.closeEditor()
+}
+
+// EditorRect returns size of plugin's editor.
+func (p *Plugin) EditorRect() (width, height int) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	var rect *C.ERect
+	p.dispatch(vst2.EffEditGetRect, 0, 0, unsafe.Pointer(&rect), 0)
+	if rect == nil {
+		return 0, 0
+	}
+	return int(rect.right - rect.left), int(rect.bottom - rect.top)
+}
+
+// idleEditor sends idle calls to plugin until done is closed.
+func (p *Plugin) idleEditor(done chan struct{}) {
+	ticker := time.NewTicker(editorIdleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			p.m.Lock()
+			// editor could be closed while waiting for the lock.
+			if p.editorDone == done {
+				p.dispatch(vst2.EffEditIdle, 0, 0, nil, 0)
+			}
+			p.m.Unlock()
+		}
+	}
+}
+
+// closeEditor closes editor if it's open. Caller must hold the lock.
+func (p *Plugin) closeEditor() {
+	if p.editorDone == nil {
+		return
+	}
+	close(p.editorDone)
+	p.editorDone = nil
+	atomic.StoreInt32(&p.editorOpen, 0)
+	p.dispatch(vst2.EffEditClose, 0, 0, nil, 0)
+}
@@ -0,0 +1,75 @@
+package vst2
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Kinds of plugin errors. Returned errors wrap them with context, so they
+// should be checked with errors.Is.
+var (
+	// ErrNotFound is returned when plugin's file doesn't exist.
+	ErrNotFound = errors.New("Plugin not found")
+	// ErrInvalidPlugin is returned when file isn't a valid vst2 plugin or plugin failed to load.
+	ErrInvalidPlugin = errors.New("Invalid plugin")
+	// ErrUnsupportedOpcode is returned when plugin doesn't support requested operation.
+	ErrUnsupportedOpcode = errors.New("Opcode isn't supported by plugin")
+	// ErrProcessFailed is returned when plugin failed to process buffer.
+	ErrProcessFailed = errors.New("Plugin failed to process buffer")
+)
+
+var (
+	// ErrClosed is returned when plugin is created from closed library.
+	ErrClosed = errors.New("Library is closed")
+	// ErrProcessing is returned when plugin is closed while processing is started.
+	ErrProcessing = errors.New("Plugin is processing")
+	// ErrFaulted is returned when plugin didn't finish processing in time.
+	// Stuck plugin isn't called anymore.
+	ErrFaulted = fmt.Errorf("%w: plugin is faulted", ErrProcessFailed)
+	// ErrNoChunks is returned when plugin doesn't store its state in chunks.
+	ErrNoChunks = fmt.Errorf("%w: plugin doesn't support chunks", ErrUnsupportedOpcode)
+	// ErrInvalidPreset is returned when preset file has invalid format.
+	ErrInvalidPreset = errors.New("Invalid preset file")
+	// ErrNoEditor is returned when plugin doesn't have editor.
+	ErrNoEditor = fmt.Errorf("%w: plugin doesn't have editor", ErrUnsupportedOpcode)
+	// ErrNoWindow is returned when editor is opened without parent window.
+	ErrNoWindow = errors.New("Parent window is not provided")
+	// ErrPoolClosed is returned by Get when processor pool is closed.
+	ErrPoolClosed = errors.New("Processor pool is closed")
+)
+
+// ScanErrors contains errors of plugins which failed to load during scan.
+// Key is a path of plugin.
+type ScanErrors map[string]error
+
+// Error returns all errors, one per line.
+func (e ScanErrors) Error() string {
+	return formatErrors(e)
+}
+
+// BatchErrors contains errors of files which failed to render. Key is a path of input file.
+type BatchErrors map[string]error
+
+// Error returns all errors, one per line.
+func (e BatchErrors) Error() string {
+	return formatErrors(e)
+}
+
+// formatErrors returns errors sorted by path, one per line.
+func formatErrors(errs map[string]error) string {
+	paths := make([]string, 0, len(errs))
+	for path := range errs {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	var b strings.Builder
+	for i, path := range paths {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "%v: %v", path, errs[path])
+	}
+	return b.String()
+}
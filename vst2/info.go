@@ -0,0 +1,132 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+// #include "aeffectx.h"
+import "C"
+
+import (
+	"fmt"
+
+	"github.com/dudk/vst2"
+)
+
+// PluginInfo describes plugin.
+type PluginInfo struct {
+	Path       string
+	Name       string
+	Vendor     string
+	Product    string
+	Version    int // vendor-specific version.
+	VSTVersion int // version of VST SDK, e.g. 2400 for 2.4.
+	UniqueID   int32
+	NumInputs  int
+	NumOutputs int
+	IsSynth    bool // true for instruments, false for effects.
+	Category   PluginCategory
+	Flags      int32 // raw effect flags.
+
+	// Capabilities derived from flags.
+	HasEditor          bool
+	ProgramsAreChunks  bool // state is stored in chunks instead of parameters.
+	CanReplacing       bool // plugin processes float32.
+	CanDoubleReplacing bool // plugin processes float64.
+}
+
+// PluginCategory is a category reported by plugin.
+type PluginCategory int
+
+// Plugin categories.
+const (
+	CategoryUnknown        PluginCategory = C.kPlugCategUnknown
+	CategoryEffect         PluginCategory = C.kPlugCategEffect
+	CategorySynth          PluginCategory = C.kPlugCategSynth
+	CategoryAnalysis       PluginCategory = C.kPlugCategAnalysis
+	CategoryMastering      PluginCategory = C.kPlugCategMastering
+	CategorySpatializer    PluginCategory = C.kPlugCategSpacializer
+	CategoryRoomFx         PluginCategory = C.kPlugCategRoomFx
+	CategorySurroundFx     PluginCategory = C.kPlugSurroundFx
+	CategoryRestoration    PluginCategory = C.kPlugCategRestoration
+	CategoryOfflineProcess PluginCategory = C.kPlugCategOfflineProcess
+	CategoryShell          PluginCategory = C.kPlugCategShell
+	CategoryGenerator      PluginCategory = C.kPlugCategGenerator
+)
+
+var categoryNames = map[PluginCategory]string{
+	CategoryUnknown:        "Unknown",
+	CategoryEffect:         "Effect",
+	CategorySynth:          "Synth",
+	CategoryAnalysis:       "Analysis",
+	CategoryMastering:      "Mastering",
+	CategorySpatializer:    "Spatializer",
+	CategoryRoomFx:         "RoomFx",
+	CategorySurroundFx:     "SurroundFx",
+	CategoryRestoration:    "Restoration",
+	CategoryOfflineProcess: "OfflineProcess",
+	CategoryShell:          "Shell",
+	CategoryGenerator:      "Generator",
+}
+
+// String returns name of category.
+func (c PluginCategory) String() string {
+	if name, ok := categoryNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("PluginCategory(%d)", int(c))
+}
+
+// Info returns plugin's metadata. It's read when plugin is wrapped.
+func (p *Plugin) Info() PluginInfo {
+	return p.metadata
+}
+
+// IsSynth returns true if plugin is an instrument. Plugins with
+// ambiguous category are treated as effects.
+func (p *Plugin) IsSynth() bool {
+	return p.metadata.IsSynth
+}
+
+// Category returns category reported by plugin. It's read when plugin is wrapped.
+func (p *Plugin) Category() PluginCategory {
+	return p.metadata.Category
+}
+
+// HasEditor returns true if plugin has editor, so it can be opened with OpenEditor.
+func (p *Plugin) HasEditor() bool {
+	return p.metadata.HasEditor
+}
+
+// info returns plugin's metadata. Caller must hold the lock.
+func (p *Plugin) info() PluginInfo {
+	version := int(p.dispatch(vst2.EffGetVendorVersion, 0, 0, nil, 0))
+	if version == 0 {
+		version = int(p.version())
+	}
+	info := PluginInfo{
+		Path:       p.Path,
+		Name:       p.getString(vst2.EffGetEffectName, 0),
+		Vendor:     p.getString(vst2.EffGetVendorString, 0),
+		Product:    p.getString(vst2.EffGetProductString, 0),
+		Version:    version,
+		VSTVersion: int(p.dispatch(vst2.EffGetVstVersion, 0, 0, nil, 0)),
+		UniqueID:   p.uniqueID(),
+		Category:   p.category(),
+	}
+	info.IsSynth = p.hasFlag(C.effFlagsIsSynth) || info.Category == CategorySynth
+	if e := p.effect(); e != nil {
+		info.NumInputs = int(e.numInputs)
+		info.NumOutputs = int(e.numOutputs)
+		info.Flags = int32(e.flags)
+	}
+	info.HasEditor = p.hasFlag(C.effFlagsHasEditor)
+	info.ProgramsAreChunks = p.hasFlag(C.effFlagsProgramChunks)
+	info.CanReplacing = p.hasFlag(C.effFlagsCanReplacing)
+	info.CanDoubleReplacing = p.hasFlag(C.effFlagsCanDoubleReplacing)
+	return info
+}
+
+// category asks plugin for its category. Caller must hold the lock.
+func (p *Plugin) category() PluginCategory {
+	return PluginCategory(p.dispatch(vst2.EffGetPlugCategory, 0, 0, nil, 0))
+}
@@ -0,0 +1,55 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+/*
+#include "aeffectx.h"
+*/
+import "C"
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/dudk/vst2"
+)
+
+// errLayout is returned when plugin is opened with version of vst2 package
+// which has unexpected layout of structures. vst2 package doesn't export
+// AEffect of plugin and entry point of library, so they're accessed through
+// unexported fields. Layout is checked once, so a new version of dependency
+// can't silently corrupt memory.
+var errLayout = checkLayout()
+
+// checkLayout verifies that unexported fields used by this package have
+// expected offsets and types.
+func checkLayout() error {
+	plugin := reflect.TypeOf(vst2.Plugin{})
+	if err := checkField(plugin, "effect", 0, reflect.Ptr, C.sizeof_AEffect); err != nil {
+		return err
+	}
+	// plugins of shell libraries are created without vst2 package.
+	if err := checkField(plugin, "timeInfo", anyOffset, reflect.Ptr, C.sizeof_struct_VstTimeInfo); err != nil {
+		return err
+	}
+	return checkField(reflect.TypeOf(vst2.Library{}), "entryPoint", 0, reflect.UnsafePointer, 0)
+}
+
+// anyOffset is passed to checkField for fields which are accessed by name.
+const anyOffset = ^uintptr(0)
+
+// checkField verifies offset and kind of field. Size of pointer's element
+// is verified if it isn't zero.
+func checkField(t reflect.Type, name string, offset uintptr, kind reflect.Kind, size uintptr) error {
+	f, ok := t.FieldByName(name)
+	switch {
+	case !ok:
+		return fmt.Errorf("Unsupported version of vst2 package: %v.%v not found", t, name)
+	case offset != anyOffset && f.Offset != offset, f.Type.Kind() != kind:
+		return fmt.Errorf("Unsupported version of vst2 package: %v.%v is %v at offset %d", t, name, f.Type, f.Offset)
+	case size != 0 && f.Type.Elem().Size() != size:
+		return fmt.Errorf("Unsupported version of vst2 package: %v.%v points to %d bytes, expected %d", t, name, f.Type.Elem().Size(), size)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayout(t *testing.T) {
+	assert.Nil(t, checkLayout())
+
+	type layout struct {
+		other  int
+		effect *int
+	}
+	typ := reflect.TypeOf(layout{})
+	assert.NotNil(t, checkField(typ, "missing", 0, reflect.Ptr, 0))
+	assert.NotNil(t, checkField(typ, "effect", 0, reflect.Ptr, 0))
+	assert.NotNil(t, checkField(typ, "other", 0, reflect.Ptr, 0))
+	assert.NotNil(t, checkField(typ, "effect", typ.Field(1).Offset, reflect.Ptr, 1))
+	assert.Nil(t, checkField(typ, "effect", typ.Field(1).Offset, reflect.Ptr, 0))
+	assert.Nil(t, checkField(typ, "effect", anyOffset, reflect.Ptr, 0))
+	assert.NotNil(t, checkField(typ, "other", anyOffset, reflect.Ptr, 0))
+}
@@ -0,0 +1,77 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/dudk/vst2"
+)
+
+// Library is a loaded plugin binary. Multiple plugins can be created from
+// the same library. It must be closed after all its plugins are closed.
+type Library struct {
+	Path   string
+	m      sync.Mutex
+	lib    *vst2.Library
+	closed bool
+}
+
+// OpenLibrary loads plugin binary. Path is resolved the same way as in Open.
+func OpenLibrary(path string) (*Library, error) {
+	if errLayout != nil {
+		return nil, errLayout
+	}
+	path, err := resolvePath(path)
+	if err != nil {
+		return nil, err
+	}
+	lib, err := vst2.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to load library %v: %v", ErrInvalidPlugin, path, err)
+	}
+	return &Library{
+		Path: path,
+		lib:  lib,
+	}, nil
+}
+
+// Open creates new plugin instance.
+func (l *Library) Open() (plugin *Plugin, err error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if l.closed {
+		return nil, ErrClosed
+	}
+	// misbehaving plugins can panic during creation.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v failed to load: %v", ErrInvalidPlugin, l.Path, r)
+		}
+	}()
+	p, err := l.lib.Open()
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to open %v: %v", ErrInvalidPlugin, l.Path, err)
+	}
+	plugin = NewPlugin(p)
+	if plugin.effect() == nil {
+		return nil, fmt.Errorf("%w: %v failed to load: entry point returned no effect", ErrInvalidPlugin, l.Path)
+	}
+	return plugin, nil
+}
+
+// Close unloads library. Plugins of library must not be used after that.
+// Subsequent calls are no-op.
+func (l *Library) Close() error {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if l.closed {
+		return nil
+	}
+	l.closed = true
+	// result of vst2.Library.Close differs between platforms.
+	l.lib.Close()
+	return nil
+}
@@ -0,0 +1,120 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+/*
+#include <stdlib.h>
+#include "aeffectx.h"
+
+// newMidiEvents allocates VstEvents with n midi events. VstEvents declares
+// space only for two pointers, so the rest is allocated in the end of structure.
+static VstEvents *newMidiEvents(int n) {
+	VstEvents *events = calloc(1, sizeof(VstEvents) + n * sizeof(VstEvent *));
+	VstMidiEvent *midi = calloc(n, sizeof(VstMidiEvent));
+	events->numEvents = n;
+	for (int i = 0; i < n; i++) {
+		midi[i].type = kVstMidiType;
+		midi[i].byteSize = sizeof(VstMidiEvent);
+		events->events[i] = (VstEvent *)&midi[i];
+	}
+	return events;
+}
+
+static void setMidiEvent(VstEvents *events, int i, int deltaFrames, char status, char data1, char data2) {
+	VstMidiEvent *midi = (VstMidiEvent *)events->events[i];
+	midi->deltaFrames = deltaFrames;
+	midi->midiData[0] = status;
+	midi->midiData[1] = data1;
+	midi->midiData[2] = data2;
+}
+
+static void freeMidiEvents(VstEvents *events) {
+	if (events->numEvents > 0) {
+		free(events->events[0]);
+	}
+	free(events);
+}
+*/
+import "C"
+
+import (
+	"sort"
+	"sync"
+	"unsafe"
+
+	"github.com/dudk/vst2"
+)
+
+// MIDIEvent is a MIDI message which is sent to plugin.
+type MIDIEvent struct {
+	DeltaFrames int     // offset in samples from the start of buffer.
+	Data        [3]byte // MIDI message: status byte and two data bytes.
+}
+
+// midiQueue holds MIDI events until they're sent to plugin.
+type midiQueue struct {
+	m      sync.Mutex
+	events []MIDIEvent
+}
+
+// push adds events to queue.
+func (q *midiQueue) push(events []MIDIEvent) {
+	q.m.Lock()
+	q.events = append(q.events, events...)
+	q.m.Unlock()
+}
+
+// clear removes all queued events.
+func (q *midiQueue) clear() {
+	q.m.Lock()
+	q.events = nil
+	q.m.Unlock()
+}
+
+// pop returns sorted events which fit into buffer. Other events are carried over:
+// their delta frames are shifted, so they're aligned to the next buffer.
+func (q *midiQueue) pop(bufferSize int) []MIDIEvent {
+	q.m.Lock()
+	defer q.m.Unlock()
+	if len(q.events) == 0 {
+		return nil
+	}
+	sort.SliceStable(q.events, func(i, j int) bool {
+		return q.events[i].DeltaFrames < q.events[j].DeltaFrames
+	})
+	i := sort.Search(len(q.events), func(i int) bool {
+		return q.events[i].DeltaFrames >= bufferSize
+	})
+	result := make([]MIDIEvent, i)
+	copy(result, q.events[:i])
+	q.events = q.events[i:]
+	for j := range q.events {
+		q.events[j].DeltaFrames -= bufferSize
+	}
+	return result
+}
+
+// ProcessMIDI sends MIDI events to plugin. Events are applied to the next processed buffer.
+func (p *Plugin) ProcessMIDI(events []MIDIEvent) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.freeEvents()
+	if len(events) == 0 {
+		return
+	}
+	// events must be valid until the next process call, so they are freed on the next dispatch.
+	p.events = C.newMidiEvents(C.int(len(events)))
+	for i, e := range events {
+		C.setMidiEvent(p.events, C.int(i), C.int(e.DeltaFrames), C.char(e.Data[0]), C.char(e.Data[1]), C.char(e.Data[2]))
+	}
+	p.dispatch(vst2.EffProcessEvents, 0, 0, unsafe.Pointer(p.events), 0)
+}
+
+// freeEvents releases events sent to plugin.
+func (p *Plugin) freeEvents() {
+	if p.events != nil {
+		C.freeMidiEvents(p.events)
+		p.events = nil
+	}
+}
@@ -0,0 +1,30 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMIDIQueue(t *testing.T) {
+	var q midiQueue
+	q.push([]MIDIEvent{
+		{DeltaFrames: 700, Data: [3]byte{0x80, 60, 0}},
+		{DeltaFrames: 10, Data: [3]byte{0x90, 60, 100}},
+		{DeltaFrames: 512, Data: [3]byte{0x90, 62, 100}},
+	})
+
+	events := q.pop(512)
+	assert.Equal(t, 1, len(events))
+	assert.Equal(t, 10, events[0].DeltaFrames)
+
+	events = q.pop(512)
+	assert.Equal(t, 2, len(events))
+	assert.Equal(t, 0, events[0].DeltaFrames)
+	assert.Equal(t, 188, events[1].DeltaFrames)
+
+	assert.Nil(t, q.pop(512))
+}
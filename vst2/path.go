@@ -0,0 +1,92 @@
+package vst2
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolvePath expands ~ in plugin path, makes it absolute and checks that
+// plugin's binary exists. Returned path is passed to library loader, so bundle
+// path is returned for bundles.
+func resolvePath(path string) (string, error) {
+	expanded, err := expandHome(path)
+	if err != nil {
+		return "", fmt.Errorf("Failed to expand path %v: %v", path, err)
+	}
+	abs, err := filepath.Abs(expanded)
+	if err != nil {
+		return "", fmt.Errorf("Failed to resolve path %v: %v", path, err)
+	}
+	if _, err := pluginBinary(abs); err != nil {
+		return "", err
+	}
+	return abs, nil
+}
+
+// bundleBinary returns path of binary inside of macOS bundle. Binary is
+// expected to have the same name as bundle, otherwise the only file in
+// Contents/MacOS is used.
+func bundleBinary(bundle string) (string, error) {
+	if _, err := os.Stat(bundle); os.IsNotExist(err) {
+		return "", fmt.Errorf("%w: %v", ErrNotFound, bundle)
+	}
+	dir := filepath.Join(bundle, "Contents", "MacOS")
+	name := strings.TrimSuffix(filepath.Base(bundle), filepath.Ext(bundle))
+	if fi, err := os.Stat(filepath.Join(dir, name)); err == nil && !fi.IsDir() {
+		return filepath.Join(dir, name), nil
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("%w: failed to read bundle %v: %v", ErrInvalidPlugin, bundle, err)
+	}
+	var binaries []string
+	for _, fi := range files {
+		if !fi.IsDir() {
+			binaries = append(binaries, filepath.Join(dir, fi.Name()))
+		}
+	}
+	if len(binaries) != 1 {
+		return "", fmt.Errorf("%w: bundle %v doesn't have binary in Contents/MacOS", ErrInvalidPlugin, bundle)
+	}
+	return binaries[0], nil
+}
+
+// isBundle checks if directory is a vst2 bundle of macOS, e.g. it has
+// .vst extension and Contents/MacOS inside.
+func isBundle(path string) bool {
+	if !strings.EqualFold(filepath.Ext(path), ".vst") {
+		return false
+	}
+	fi, err := os.Stat(filepath.Join(path, "Contents", "MacOS"))
+	return err == nil && fi.IsDir()
+}
+
+// fileBinary checks that plugin's binary exists.
+func fileBinary(path string) (string, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("%w: %v", ErrNotFound, path)
+		}
+		return "", fmt.Errorf("Failed to read plugin %v: %v", path, err)
+	}
+	if fi.IsDir() {
+		return "", fmt.Errorf("%w: %v is a directory", ErrInvalidPlugin, path)
+	}
+	return path, nil
+}
+
+// expandHome replaces leading ~ in path with home directory.
+func expandHome(path string) (string, error) {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, path[1:]), nil
+}
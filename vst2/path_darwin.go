@@ -0,0 +1,9 @@
+package vst2
+
+// pluginBinary returns path of binary inside of plugin's bundle.
+func pluginBinary(path string) (string, error) {
+	if _, err := fileBinary(path); err == nil {
+		return path, nil
+	}
+	return bundleBinary(path)
+}
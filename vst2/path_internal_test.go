@@ -0,0 +1,53 @@
+package vst2
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBundleBinary(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phono")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	bundle := filepath.Join(dir, "Plugin.vst")
+	macos := filepath.Join(bundle, "Contents", "MacOS")
+	assert.Nil(t, os.MkdirAll(macos, 0755))
+
+	_, err = bundleBinary(bundle)
+	assert.NotNil(t, err)
+
+	// the only binary is used.
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(macos, "Binary"), nil, 0644))
+	binary, err := bundleBinary(bundle)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(macos, "Binary"), binary)
+
+	// binary with bundle name is preferred.
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(macos, "Plugin"), nil, 0644))
+	binary, err = bundleBinary(bundle)
+	assert.Nil(t, err)
+	assert.Equal(t, filepath.Join(macos, "Plugin"), binary)
+
+	_, err = bundleBinary(filepath.Join(dir, "not-existing.vst"))
+	assert.NotNil(t, err)
+}
+
+func TestIsBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phono")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	bundle := filepath.Join(dir, "Plugin.vst")
+	assert.Nil(t, os.MkdirAll(bundle, 0755))
+	assert.False(t, isBundle(bundle))
+
+	assert.Nil(t, os.MkdirAll(filepath.Join(bundle, "Contents", "MacOS"), 0755))
+	assert.True(t, isBundle(bundle))
+
+	other := filepath.Join(dir, "Plugins", "Contents", "MacOS")
+	assert.Nil(t, os.MkdirAll(other, 0755))
+	assert.False(t, isBundle(filepath.Join(dir, "Plugins")))
+}
@@ -0,0 +1,9 @@
+//go:build !darwin
+// +build !darwin
+
+package vst2
+
+// pluginBinary returns path of plugin's binary.
+func pluginBinary(path string) (string, error) {
+	return fileBinary(path)
+}
@@ -0,0 +1,728 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+/*
+// VST2 SDK headers aren't redistributed, they're found with include path
+// provided by CGO_CFLAGS=-I/path/to/vstsdk2.4/pluginterfaces/vst2.x.
+#cgo CFLAGS: -std=gnu99
+
+#include <stdlib.h>
+#include "aeffectx.h"
+
+static VstIntPtr dispatch(AEffect *effect, int opcode, int index, VstIntPtr value, void *ptr, float opt) {
+	return effect->dispatcher(effect, opcode, index, value, ptr, opt);
+}
+
+static float getParameter(AEffect *effect, int index) {
+	return effect->getParameter(effect, index);
+}
+
+static void setParameter(AEffect *effect, int index, float value) {
+	effect->setParameter(effect, index, value);
+}
+*/
+import "C"
+
+import (
+	"math"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/dudk/vst2"
+)
+
+// Plugin wraps vst2 plugin and exposes the plugin API which is not covered by vst2 package.
+// All calls into plugin are serialized, so it's safe to use Plugin while Processor is running.
+type Plugin struct {
+	*vst2.Plugin
+	m          sync.Mutex
+	events     *C.VstEvents // events sent to plugin with last dispatch.
+	float32    bool         // plugin processes float32, cached on open and resume.
+	precision  Precision
+	buffers    processBuffers
+	blockSize  int // buffer size set with SetBufferSize.
+	metadata   PluginInfo
+	parameters map[string]int              // index of the first parameter with name.
+	editorDone chan struct{}               // closed when editor is closed.
+	editorOpen int32                       // 1 while editor is open, read by callback without lock.
+	library    *Library                    // closed with plugin if plugin is created with Open.
+	shellID    int                         // id of plugin opened with OpenShell.
+	faulted    int32                       // 1 if call didn't return in time, accessed atomically.
+	speakers   [2]*C.VstSpeakerArrangement // input and output arrangements sent to plugin.
+	sanitize   bool                        // replace NaN and Inf output samples with zero.
+	sanitized  int64                       // number of replaced samples.
+	started    bool                        // processing is started and not stopped yet.
+	closed     bool                        // Close is called.
+	callback   atomic.Value                // vst2.HostCallbackFunc called by plugin.
+	callbackM  sync.Mutex                  // serializes callback swaps.
+}
+
+// maxStringLen is a size of buffer used to receive strings from plugin.
+// VST2 limits parameter strings to 8 chars, but most plugins don't respect it.
+const maxStringLen = C.kVstMaxLabelLen
+
+// Process levels reported to plugin.
+const (
+	processLevelUser     = int(C.kVstProcessLevelUser)
+	processLevelRealtime = int(C.kVstProcessLevelRealtime)
+	processLevelOffline  = int(C.kVstProcessLevelOffline)
+)
+
+// Precision defines sample type used to process buffers.
+type Precision int
+
+const (
+	// Auto processes float32 if plugin supports it and float64 otherwise.
+	Auto Precision = iota
+	// Float32 is used if plugin supports it.
+	Float32
+	// Float64 is used if plugin supports it.
+	Float64
+)
+
+// vstVersion is a version of VST SDK reported to plugins.
+const vstVersion = 2400
+
+// hostName is reported to plugins as host vendor and product.
+const hostName = "phono"
+
+// Open loads library and creates plugin. Library is closed when plugin is closed.
+// Leading ~ in path is expanded and relative path is resolved against working
+// directory. On macOS path can point to bundle directory.
+func Open(path string) (*Plugin, error) {
+	lib, err := OpenLibrary(path)
+	if err != nil {
+		return nil, err
+	}
+	plugin, err := lib.Open()
+	if err != nil {
+		lib.Close()
+		return nil, err
+	}
+	plugin.library = lib
+	return plugin, nil
+}
+
+// NewPlugin wraps vst2 plugin. Host callback is replaced with the one which
+// is safe to be called before plugin is used by Processor.
+func NewPlugin(p *vst2.Plugin) *Plugin {
+	plugin := &Plugin{
+		Plugin: p,
+	}
+	plugin.callback.Store(vst2.HostCallbackFunc(hostCallback))
+	// vst2.Plugin reads its callback without synchronization, so it's set
+	// once and calls are forwarded to the current callback.
+	p.SetCallback(plugin.forwardCallback)
+	plugin.float32 = plugin.useFloat32()
+	plugin.metadata = plugin.info()
+	plugin.parameters = plugin.parameterIndices()
+	return plugin
+}
+
+// SetCallback replaces host callback. It's safe to call while plugin is
+// processing, calls which are in progress finish with previous callback.
+// Nil callback restores the default one.
+func (p *Plugin) SetCallback(c vst2.HostCallbackFunc) {
+	p.WithCallback(c)
+}
+
+// WithCallback replaces host callback the same way as SetCallback and
+// returns the previous one, so it can be restored later.
+func (p *Plugin) WithCallback(c vst2.HostCallbackFunc) vst2.HostCallbackFunc {
+	if c == nil {
+		c = hostCallback
+	}
+	p.callbackM.Lock()
+	defer p.callbackM.Unlock()
+	previous := p.callback.Load().(vst2.HostCallbackFunc)
+	p.callback.Store(c)
+	return previous
+}
+
+// forwardCallback calls current host callback.
+func (p *Plugin) forwardCallback(plugin *vst2.Plugin, opcode vst2.MasterOpcode, index int64, value int64, ptr unsafe.Pointer, opt float64) int {
+	c := p.callback.Load().(vst2.HostCallbackFunc)
+	return c(plugin, opcode, index, value, ptr, opt)
+}
+
+// hostCallback answers calls which plugin makes before Processor sets its callback.
+func hostCallback(plugin *vst2.Plugin, opcode vst2.MasterOpcode, index int64, value int64, ptr unsafe.Pointer, opt float64) int {
+	result, _ := hostInfo(opcode, ptr)
+	return result
+}
+
+// hostInfo answers queries about host. It returns false if opcode isn't a host query.
+func hostInfo(opcode vst2.MasterOpcode, ptr unsafe.Pointer) (int, bool) {
+	switch opcode {
+	case vst2.AudioMasterVersion:
+		return vstVersion, true
+	case vst2.AudioMasterGetVendorString:
+		return writeString(ptr, hostName, C.kVstMaxVendorStrLen), true
+	case vst2.AudioMasterGetProductString:
+		return writeString(ptr, hostName, C.kVstMaxProductStrLen), true
+	case vst2.AudioMasterGetLanguage:
+		return C.kVstLangEnglish, true
+	}
+	return 0, false
+}
+
+// writeString copies null-terminated string into buffer provided by plugin.
+// String is truncated to fit the buffer. It returns 1 if string is written.
+func writeString(ptr unsafe.Pointer, s string, size int) int {
+	if ptr == nil {
+		return 0
+	}
+	buf := (*[maxSamples]byte)(ptr)[:size:size]
+	n := copy(buf[:size-1], s)
+	buf[n] = 0
+	return 1
+}
+
+// effect returns AEffect of underlying vst2 plugin. vst2.Plugin doesn't export it,
+// but it's the first field of the structure, so the pointer to plugin points to it.
+// Layout is verified by checkLayout, nil is returned if it doesn't match.
+func (p *Plugin) effect() *C.AEffect {
+	if p.Plugin == nil || errLayout != nil {
+		return nil
+	}
+	return *(**C.AEffect)(unsafe.Pointer(p.Plugin))
+}
+
+// Process is a thread-safe version of vst2.Plugin.Process.
+// If plugin can process float32, conversion is done. Memory passed
+// to plugin is reused between calls. Plugin receives as many channels
+// as it has inputs: missing ones are silent and extra ones are ignored.
+// Result has a channel for every plugin's output. Buffer shorter than
+// the size set with SetBufferSize is padded with silence, because
+// plugins expect full blocks. Result has the length of input buffer.
+func (p *Plugin) Process(b [][]float64) [][]float64 {
+	if len(b) == 0 || b[0] == nil {
+		return nil
+	}
+	p.m.Lock()
+	defer p.m.Unlock()
+	e := p.effect()
+	if e == nil {
+		return nil
+	}
+	p.buffers.resize(p.metadata.NumInputs, p.metadata.NumOutputs, p.paddedSize(len(b[0])), p.float32)
+	p.buffers.write(b)
+	p.buffers.process(e)
+	result := p.buffers.read(len(b[0]))
+	if p.sanitize {
+		p.sanitized += sanitize(result)
+	}
+	return result
+}
+
+// ProcessFloat32 is a version of Process for float32 samples. If plugin
+// processes float32, samples are passed without conversion. Use it when
+// both source and destination of samples are float32.
+func (p *Plugin) ProcessFloat32(b [][]float32) [][]float32 {
+	if len(b) == 0 || b[0] == nil {
+		return nil
+	}
+	p.m.Lock()
+	defer p.m.Unlock()
+	e := p.effect()
+	if e == nil {
+		return nil
+	}
+	p.buffers.resize(p.metadata.NumInputs, p.metadata.NumOutputs, p.paddedSize(len(b[0])), p.float32)
+	p.buffers.writeFloat32(b)
+	p.buffers.process(e)
+	result := p.buffers.readFloat32(len(b[0]))
+	if p.sanitize {
+		p.sanitized += sanitizeFloat32(result)
+	}
+	return result
+}
+
+// paddedSize returns number of samples passed to plugin for buffer of size.
+// Caller must hold the lock.
+func (p *Plugin) paddedSize(size int) int {
+	if size < p.blockSize {
+		return p.blockSize
+	}
+	return size
+}
+
+// SetSanitize enables check of output samples. NaN and Inf samples are
+// replaced with zero, so they don't spread to the next pipe components.
+// Check is disabled by default, because every sample has to be scanned.
+func (p *Plugin) SetSanitize(sanitize bool) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.sanitize = sanitize
+}
+
+// Sanitized returns number of NaN and Inf output samples replaced with zero.
+func (p *Plugin) Sanitized() int64 {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.sanitized
+}
+
+// PrefersFloat32 returns true if plugin processes float32. In this case
+// Process converts buffers from float64 and back, and ProcessFloat32
+// doesn't convert them at all.
+func (p *Plugin) PrefersFloat32() bool {
+	return p.float32
+}
+
+// SetPrecision sets sample type used to process buffers. If plugin
+// doesn't support requested type, the other one is used. Plugin is
+// notified about precision when it's resumed.
+func (p *Plugin) SetPrecision(precision Precision) {
+	p.m.Lock()
+	p.precision = precision
+	p.float32 = p.useFloat32()
+	p.m.Unlock()
+}
+
+// Resume is a thread-safe version of vst2.Plugin.Resume.
+// It also updates cached plugin capabilities and sends precision to plugin.
+func (p *Plugin) Resume() {
+	p.m.Lock()
+	p.float32 = p.useFloat32()
+	if p.float32 {
+		p.dispatch(vst2.EffSetProcessPrecision, 0, C.kVstProcessPrecision32, nil, 0)
+	} else {
+		p.dispatch(vst2.EffSetProcessPrecision, 0, C.kVstProcessPrecision64, nil, 0)
+	}
+	p.Plugin.Resume()
+	p.m.Unlock()
+}
+
+// Close closes plugin and releases memory allocated for it. It returns
+// ErrProcessing if processing is started and not stopped, e.g. Processor
+// isn't flushed yet. Subsequent calls are no-op. Faulted plugin is still
+// busy with the call which didn't return, so Close returns immediately and
+// the plugin with its library are leaked.
+func (p *Plugin) Close() error {
+	if p.isFaulted() {
+		return nil
+	}
+	p.m.Lock()
+	defer p.m.Unlock()
+	if p.closed {
+		return nil
+	}
+	if p.started {
+		return ErrProcessing
+	}
+	p.closed = true
+	p.closeEditor()
+	p.freeEvents()
+	p.buffers.free()
+	p.freeSpeakerArrangement()
+	effect := p.effect()
+	err := p.Plugin.Close()
+	closeShell(effect)
+	if p.library != nil {
+		p.library.Close()
+		p.library = nil
+	}
+	return err
+}
+
+// fault marks plugin which didn't return from processing in time.
+func (p *Plugin) fault() {
+	atomic.StoreInt32(&p.faulted, 1)
+}
+
+// isFaulted returns true if plugin didn't return from processing in time.
+func (p *Plugin) isFaulted() bool {
+	return atomic.LoadInt32(&p.faulted) == 1
+}
+
+// Suspend is a thread-safe version of vst2.Plugin.Suspend.
+func (p *Plugin) Suspend() {
+	p.m.Lock()
+	p.Plugin.Suspend()
+	p.m.Unlock()
+}
+
+// StartProcess tells plugin that processing is started. It should be
+// called after plugin is resumed and before the first buffer is processed.
+func (p *Plugin) StartProcess() {
+	p.m.Lock()
+	p.started = true
+	p.dispatch(vst2.EffStartProcess, 0, 0, nil, 0)
+	p.m.Unlock()
+}
+
+// StopProcess tells plugin that processing is stopped. It should be
+// called after the last buffer is processed and before plugin is suspended.
+func (p *Plugin) StopProcess() {
+	p.m.Lock()
+	p.started = false
+	p.dispatch(vst2.EffStopProcess, 0, 0, nil, 0)
+	p.m.Unlock()
+}
+
+// SetBufferSize is a thread-safe version of vst2.Plugin.SetBufferSize.
+func (p *Plugin) SetBufferSize(bufferSize int) {
+	p.m.Lock()
+	p.Plugin.SetBufferSize(bufferSize)
+	p.blockSize = bufferSize
+	p.m.Unlock()
+}
+
+// SetSampleRate is a thread-safe version of vst2.Plugin.SetSampleRate.
+func (p *Plugin) SetSampleRate(sampleRate int) {
+	p.m.Lock()
+	p.Plugin.SetSampleRate(sampleRate)
+	p.m.Unlock()
+}
+
+// NumInputs returns a number of plugin's input channels. It's read when plugin is wrapped.
+func (p *Plugin) NumInputs() int {
+	return p.metadata.NumInputs
+}
+
+// NumOutputs returns a number of plugin's output channels. It's read when plugin is wrapped.
+func (p *Plugin) NumOutputs() int {
+	return p.metadata.NumOutputs
+}
+
+// NumParameters returns a number of plugin's parameters.
+func (p *Plugin) NumParameters() int {
+	e := p.effect()
+	if e == nil {
+		return 0
+	}
+	return int(e.numParams)
+}
+
+// InitialDelay returns latency of plugin in samples. It's 0 if plugin doesn't report it.
+func (p *Plugin) InitialDelay() int {
+	e := p.effect()
+	if e == nil {
+		return 0
+	}
+	return int(e.initialDelay)
+}
+
+// InfiniteTail is returned by TailSize if plugin's tail never ends, e.g. infinite reverb.
+const InfiniteTail = math.MaxInt32
+
+// TailSize returns a number of samples which plugin outputs after input is done,
+// e.g. reverb decay. It's 0 if plugin doesn't have a tail and InfiniteTail if
+// tail never ends.
+func (p *Plugin) TailSize() int {
+	p.m.Lock()
+	defer p.m.Unlock()
+	// 1 means "no tail" and 0 means "not supported".
+	tail := p.dispatch(vst2.EffGetTailSize, 0, 0, nil, 0)
+	switch {
+	case tail < 0 || tail >= InfiniteTail:
+		return InfiniteTail
+	case tail > 1:
+		return int(tail)
+	default:
+		return 0
+	}
+}
+
+// GetParameter returns a normalized value of parameter.
+func (p *Plugin) GetParameter(index int) float32 {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.getParameter(index)
+}
+
+// SetParameter sets a normalized value of parameter.
+func (p *Plugin) SetParameter(index int, value float32) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.setParameter(index, value)
+}
+
+// ParameterName returns a name of parameter, e.g. "Gain".
+func (p *Plugin) ParameterName(index int) string {
+	return p.dispatchString(vst2.EffGetParamName, index)
+}
+
+// ParameterByName returns index of parameter with name. Names are read when
+// plugin is wrapped and compared without surrounding spaces. If multiple
+// parameters have the same name, the first one is returned.
+func (p *Plugin) ParameterByName(name string) (index int, ok bool) {
+	index, ok = p.parameters[strings.TrimSpace(name)]
+	return index, ok
+}
+
+// SetParameterByName sets a normalized value of parameter with name.
+// It returns false if plugin doesn't have such parameter.
+func (p *Plugin) SetParameterByName(name string, value float32) bool {
+	index, ok := p.ParameterByName(name)
+	if ok {
+		p.SetParameter(index, value)
+	}
+	return ok
+}
+
+// parameterIndices maps names of parameters to their indices.
+func (p *Plugin) parameterIndices() map[string]int {
+	n := p.NumParameters()
+	indices := make(map[string]int, n)
+	for i := 0; i < n; i++ {
+		name := strings.TrimSpace(p.ParameterName(i))
+		if _, ok := indices[name]; !ok {
+			indices[name] = i
+		}
+	}
+	return indices
+}
+
+// ParameterLabel returns a unit label of parameter, e.g. "dB".
+func (p *Plugin) ParameterLabel(index int) string {
+	return p.dispatchString(vst2.EffGetParamLabel, index)
+}
+
+// NumPrograms returns a number of plugin's programs.
+func (p *Plugin) NumPrograms() int {
+	return p.numPrograms()
+}
+
+// CurrentProgram returns index of current program.
+func (p *Plugin) CurrentProgram() int {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.currentProgram()
+}
+
+// SetProgram selects program with index. Calls are serialized
+// with processing, so program changes between buffers.
+func (p *Plugin) SetProgram(index int) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	p.dispatch(vst2.EffBeginSetProgram, 0, 0, nil, 0)
+	p.setProgram(index)
+	p.dispatch(vst2.EffEndSetProgram, 0, 0, nil, 0)
+}
+
+// ProgramName returns a name of current program.
+func (p *Plugin) ProgramName() string {
+	return p.dispatchString(vst2.EffGetProgramName, 0)
+}
+
+// GetState returns opaque state of all plugin's programs.
+func (p *Plugin) GetState() ([]byte, error) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if !p.programChunks() {
+		return nil, ErrNoChunks
+	}
+	return p.getChunk(false), nil
+}
+
+// SetState restores state returned by GetState. It's safe to call while plugin is suspended.
+func (p *Plugin) SetState(data []byte) error {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if !p.programChunks() {
+		return ErrNoChunks
+	}
+	p.setChunk(false, data)
+	return nil
+}
+
+// SetBypass enables or disables soft bypass of plugin. It returns false if plugin
+// doesn't support soft bypass and should be bypassed by host.
+func (p *Plugin) SetBypass(bypass bool) bool {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if !p.canDo("bypass") {
+		return false
+	}
+	p.dispatch(vst2.EffSetBypass, 0, int64(boolToInt(bypass)), nil, 0)
+	return true
+}
+
+// CanDo checks if plugin supports the feature. Plugins answer yes, no or
+// don't know, the last two are reported as false. Common features are:
+//
+//	"receiveVstMidiEvent" - plugin accepts MIDI events;
+//	"sendVstMidiEvent" - plugin sends MIDI events to host;
+//	"bypass" - plugin supports soft bypass;
+//	"offline" - plugin supports offline processing;
+//	"1in1out", "1in2out", "2in2out" - plugin supports channel configuration.
+func (p *Plugin) CanDo(feature string) bool {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.canDo(feature)
+}
+
+// dispatchString dispatches opcode which returns a string through ptr argument.
+func (p *Plugin) dispatchString(opcode vst2.PluginOpcode, index int) string {
+	p.m.Lock()
+	defer p.m.Unlock()
+	return p.getString(opcode, index)
+}
+
+// Functions below call plugin without locking,
+// so the caller must hold the lock.
+
+// dispatch calls plugin's dispatcher and returns its result.
+func (p *Plugin) dispatch(opcode vst2.PluginOpcode, index int, value int64, ptr unsafe.Pointer, opt float64) int64 {
+	e := p.effect()
+	if e == nil {
+		return 0
+	}
+	return int64(C.dispatch(e, C.int(opcode), C.int(index), C.VstIntPtr(value), ptr, C.float(opt)))
+}
+
+// getString dispatches opcode which returns a string through ptr argument.
+func (p *Plugin) getString(opcode vst2.PluginOpcode, index int) string {
+	buf := make([]byte, maxStringLen)
+	p.dispatch(opcode, index, 0, unsafe.Pointer(&buf[0]), 0)
+	return C.GoString((*C.char)(unsafe.Pointer(&buf[0])))
+}
+
+// useFloat32 returns true if buffers should be processed as float32.
+func (p *Plugin) useFloat32() bool {
+	// legacy plugins without replacing functions process float32 only.
+	if !p.Plugin.CanProcessFloat32() && !p.Plugin.CanProcessFloat64() {
+		return true
+	}
+	switch p.precision {
+	case Float64:
+		return !p.Plugin.CanProcessFloat64()
+	case Float32:
+		return p.Plugin.CanProcessFloat32() || !p.Plugin.CanProcessFloat64()
+	default:
+		return p.Plugin.CanProcessFloat32()
+	}
+}
+
+// canDo checks if plugin reports the capability.
+func (p *Plugin) canDo(capability string) bool {
+	cs := C.CString(capability)
+	defer C.free(unsafe.Pointer(cs))
+	return p.dispatch(vst2.EffCanDo, 0, 0, unsafe.Pointer(cs), 0) > 0
+}
+
+func (p *Plugin) getParameter(index int) float32 {
+	e := p.effect()
+	if e == nil {
+		return 0
+	}
+	return float32(C.getParameter(e, C.int(index)))
+}
+
+func (p *Plugin) setParameter(index int, value float32) {
+	e := p.effect()
+	if e == nil {
+		return
+	}
+	C.setParameter(e, C.int(index), C.float(value))
+}
+
+func (p *Plugin) uniqueID() int32 {
+	e := p.effect()
+	if e == nil {
+		return 0
+	}
+	return int32(e.uniqueID)
+}
+
+func (p *Plugin) version() int32 {
+	e := p.effect()
+	if e == nil {
+		return 0
+	}
+	return int32(e.version)
+}
+
+func (p *Plugin) numPrograms() int {
+	e := p.effect()
+	if e == nil {
+		return 0
+	}
+	return int(e.numPrograms)
+}
+
+// programChunks checks if plugin stores its state in chunks instead of parameters.
+func (p *Plugin) programChunks() bool {
+	return p.hasFlag(C.effFlagsProgramChunks)
+}
+
+// hasFlag checks if plugin has effect flag set.
+func (p *Plugin) hasFlag(flag C.VstInt32) bool {
+	e := p.effect()
+	if e == nil {
+		return false
+	}
+	return e.flags&flag == flag
+}
+
+func (p *Plugin) currentProgram() int {
+	return int(p.dispatch(vst2.EffGetProgram, 0, 0, nil, 0))
+}
+
+func (p *Plugin) setProgram(index int) {
+	p.dispatch(vst2.EffSetProgram, 0, int64(index), nil, 0)
+}
+
+func (p *Plugin) setProgramName(name string) {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	p.dispatch(vst2.EffSetProgramName, 0, 0, unsafe.Pointer(cname), 0)
+}
+
+// getChunk returns a copy of plugin's bank or program chunk.
+func (p *Plugin) getChunk(isProgram bool) []byte {
+	var data unsafe.Pointer
+	size := p.dispatch(vst2.EffGetChunk, boolToInt(isProgram), 0, unsafe.Pointer(&data), 0)
+	if size <= 0 || data == nil {
+		return nil
+	}
+	// chunk is owned by plugin, so it must be copied.
+	return C.GoBytes(data, C.int(size))
+}
+
+// setChunk passes bank or program chunk to plugin.
+func (p *Plugin) setChunk(isProgram bool, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	p.dispatch(vst2.EffSetChunk, boolToInt(isProgram), int64(len(data)), unsafe.Pointer(&data[0]), 0)
+}
+
+// sanitize replaces NaN and Inf samples with zero and returns number of replaced samples.
+func sanitize(b [][]float64) (n int64) {
+	for i := range b {
+		for j, v := range b[i] {
+			if math.IsNaN(v) || math.IsInf(v, 0) {
+				b[i][j] = 0
+				n++
+			}
+		}
+	}
+	return
+}
+
+// sanitizeFloat32 is a version of sanitize for float32 samples.
+func sanitizeFloat32(b [][]float32) (n int64) {
+	for i := range b {
+		for j, v := range b[i] {
+			if f := float64(v); math.IsNaN(f) || math.IsInf(f, 0) {
+				b[i][j] = 0
+				n++
+			}
+		}
+	}
+	return
+}
+
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
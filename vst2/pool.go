@@ -0,0 +1,122 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+import (
+	"fmt"
+	"sync"
+)
+
+// ProcessorPool keeps identical plugin instances, so multiple pipes can
+// process in parallel. Every instance is opened from the same library.
+// Plugin is taken with Get for the time of pipe run and returned with Put.
+type ProcessorPool struct {
+	plugins  chan *Plugin
+	settings pluginSettings // settings restored when plugin is returned.
+
+	m       sync.Mutex
+	members map[*Plugin]bool // true if plugin is taken from pool.
+	closed  bool
+}
+
+// NewProcessorPool opens size instances of plugin from library. Returned
+// plugins are reset to the settings instances have when pool is created.
+// Library must be closed after the pool.
+func NewProcessorPool(library *Library, size int) (*ProcessorPool, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("Processor pool size %v must be positive", size)
+	}
+	pool := &ProcessorPool{
+		plugins: make(chan *Plugin, size),
+		members: make(map[*Plugin]bool, size),
+	}
+	for i := 0; i < size; i++ {
+		plugin, err := library.Open()
+		if err != nil {
+			pool.Close()
+			return nil, fmt.Errorf("Failed to open plugin instance: %w", err)
+		}
+		if i == 0 {
+			pool.settings = plugin.settings()
+		}
+		pool.members[plugin] = false
+		pool.plugins <- plugin
+	}
+	return pool, nil
+}
+
+// Size returns number of plugin instances in pool.
+func (pp *ProcessorPool) Size() int {
+	return cap(pp.plugins)
+}
+
+// Get returns plugin instance. It blocks until instance is available.
+// ErrPoolClosed is returned if pool is closed.
+func (pp *ProcessorPool) Get() (*Plugin, error) {
+	plugin, ok := <-pp.plugins
+	if !ok {
+		return nil, ErrPoolClosed
+	}
+	pp.m.Lock()
+	defer pp.m.Unlock()
+	if pp.closed {
+		plugin.Close()
+		return nil, ErrPoolClosed
+	}
+	pp.members[plugin] = true
+	return plugin, nil
+}
+
+// Put returns plugin to pool. Plugin's settings are reset and the default
+// host callback is restored. Processing must be stopped, e.g. processor
+// must be flushed. Plugins which don't belong to pool or aren't taken
+// from it, e.g. returned twice, are ignored.
+func (pp *ProcessorPool) Put(plugin *Plugin) {
+	pp.m.Lock()
+	taken, member := pp.members[plugin]
+	if !member || !taken {
+		pp.m.Unlock()
+		return
+	}
+	// plugin is marked as returned before it's reset, so concurrent
+	// Put of the same plugin is ignored.
+	pp.members[plugin] = false
+	pp.m.Unlock()
+	plugin.SetCallback(nil)
+	pp.settings.apply(plugin)
+
+	pp.m.Lock()
+	defer pp.m.Unlock()
+	if pp.closed {
+		plugin.Close()
+		return
+	}
+	// channel has capacity for all members and every member is sent
+	// once, so it doesn't block.
+	pp.plugins <- plugin
+}
+
+// Close closes plugins of pool. Plugins which are taken from pool are
+// closed when they're returned. Get returns ErrPoolClosed after that.
+func (pp *ProcessorPool) Close() error {
+	pp.m.Lock()
+	defer pp.m.Unlock()
+	if pp.closed {
+		return nil
+	}
+	pp.closed = true
+	var err error
+	for {
+		select {
+		case plugin := <-pp.plugins:
+			if closeErr := plugin.Close(); err == nil {
+				err = closeErr
+			}
+		default:
+			// blocked Get returns ErrPoolClosed.
+			close(pp.plugins)
+			return err
+		}
+	}
+}
@@ -0,0 +1,262 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/dudk/vst2"
+)
+
+// Magic values of fxp/fxb files.
+const (
+	chunkMagic = "CcnK"
+	fxpParams  = "FxCk" // program with parameters.
+	fxpChunk   = "FPCh" // program with opaque chunk.
+	fxbParams  = "FxBk" // bank with programs.
+	fxbChunk   = "FBCh" // bank with opaque chunk.
+
+	// BankExtension is a file extension of bank presets.
+	BankExtension = ".fxb"
+	// ProgramExtension is a file extension of program presets.
+	ProgramExtension = ".fxp"
+
+	presetVersion     = 1
+	bankVersion       = 2
+	programNameLen    = 28
+	bankFutureLen     = 128
+	presetHeaderLen   = 28 // header size including magic, byte size and count fields.
+	presetSizeOmitted = 8  // bytes not included into byteSize: chunk magic and byte size itself.
+)
+
+// presetHeader is a common header of fxp and fxb files.
+type presetHeader struct {
+	ChunkMagic [4]byte
+	ByteSize   int32
+	FxMagic    [4]byte
+	Version    int32
+	FxID       int32
+	FxVersion  int32
+	Count      int32 // number of parameters for program, number of programs for bank.
+}
+
+// LoadPreset loads .fxp program or .fxb bank preset into plugin.
+// Format is determined by the file content.
+func (p *Plugin) LoadPreset(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	p.m.Lock()
+	defer p.m.Unlock()
+
+	r := bytes.NewReader(data)
+	h, err := p.readPresetHeader(r)
+	if err != nil {
+		return err
+	}
+	switch string(h.FxMagic[:]) {
+	case fxpParams, fxpChunk:
+		return p.readProgram(r, h)
+	case fxbParams, fxbChunk:
+		return p.readBank(r, h)
+	default:
+		return fmt.Errorf("Unknown preset fxMagic %q", h.FxMagic[:])
+	}
+}
+
+// SavePreset saves plugin state into preset file. If path has .fxb extension,
+// all programs are saved as bank. Otherwise current program is saved.
+func (p *Plugin) SavePreset(path string) error {
+	p.m.Lock()
+	var data []byte
+	if strings.EqualFold(filepath.Ext(path), BankExtension) {
+		data = p.bankPreset()
+	} else {
+		data = p.programPreset()
+	}
+	p.m.Unlock()
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// programPreset returns current program serialized in fxp format.
+func (p *Plugin) programPreset() []byte {
+	var body bytes.Buffer
+	name := make([]byte, programNameLen)
+	copy(name[:programNameLen-1], p.getString(vst2.EffGetProgramName, 0))
+	body.Write(name)
+
+	numParams := int32(p.NumParameters())
+	if p.programChunks() {
+		chunk := p.getChunk(true)
+		binary.Write(&body, binary.BigEndian, int32(len(chunk)))
+		body.Write(chunk)
+		return p.preset(fxpChunk, presetVersion, numParams, body.Bytes())
+	}
+	for i := 0; i < int(numParams); i++ {
+		binary.Write(&body, binary.BigEndian, p.getParameter(i))
+	}
+	return p.preset(fxpParams, presetVersion, numParams, body.Bytes())
+}
+
+// bankPreset returns all programs serialized in fxb format.
+func (p *Plugin) bankPreset() []byte {
+	var body bytes.Buffer
+	current := p.currentProgram()
+	future := make([]byte, bankFutureLen)
+	binary.BigEndian.PutUint32(future, uint32(current))
+	body.Write(future)
+
+	numPrograms := int32(p.numPrograms())
+	if p.programChunks() {
+		chunk := p.getChunk(false)
+		binary.Write(&body, binary.BigEndian, int32(len(chunk)))
+		body.Write(chunk)
+		return p.preset(fxbChunk, bankVersion, numPrograms, body.Bytes())
+	}
+	for i := 0; i < int(numPrograms); i++ {
+		p.setProgram(i)
+		body.Write(p.programPreset())
+	}
+	p.setProgram(current)
+	return p.preset(fxbParams, bankVersion, numPrograms, body.Bytes())
+}
+
+// preset prepends header to preset body.
+func (p *Plugin) preset(fxMagic string, version, count int32, body []byte) []byte {
+	h := presetHeader{
+		ByteSize:  int32(presetHeaderLen - presetSizeOmitted + len(body)),
+		Version:   version,
+		FxID:      p.uniqueID(),
+		FxVersion: p.version(),
+		Count:     count,
+	}
+	copy(h.ChunkMagic[:], chunkMagic)
+	copy(h.FxMagic[:], fxMagic)
+
+	var b bytes.Buffer
+	binary.Write(&b, binary.BigEndian, h)
+	b.Write(body)
+	return b.Bytes()
+}
+
+// readPresetHeader reads and validates header of preset.
+func (p *Plugin) readPresetHeader(r io.Reader) (presetHeader, error) {
+	var h presetHeader
+	if err := binary.Read(r, binary.BigEndian, &h); err != nil {
+		return h, ErrInvalidPreset
+	}
+	if string(h.ChunkMagic[:]) != chunkMagic {
+		return h, ErrInvalidPreset
+	}
+	if id := p.uniqueID(); h.FxID != id {
+		return h, fmt.Errorf("Preset is created for plugin with id %v, but loaded plugin id is %v", fourCC(h.FxID), fourCC(id))
+	}
+	return h, nil
+}
+
+// readProgram reads program preset body and applies it to current program.
+func (p *Plugin) readProgram(r *bytes.Reader, h presetHeader) error {
+	name := make([]byte, programNameLen)
+	if _, err := io.ReadFull(r, name); err != nil {
+		return ErrInvalidPreset
+	}
+	if i := bytes.IndexByte(name, 0); i >= 0 {
+		name = name[:i]
+	}
+
+	switch string(h.FxMagic[:]) {
+	case fxpChunk:
+		chunk, err := readChunk(r)
+		if err != nil {
+			return err
+		}
+		p.setChunk(true, chunk)
+	case fxpParams:
+		// count is validated before allocation, so broken file can't
+		// request arbitrary amount of memory.
+		if h.Count < 0 || int(h.Count) > p.NumParameters() {
+			return ErrInvalidPreset
+		}
+		params := make([]float32, h.Count)
+		if err := binary.Read(r, binary.BigEndian, params); err != nil {
+			return ErrInvalidPreset
+		}
+		for i := range params {
+			p.setParameter(i, params[i])
+		}
+	default:
+		return fmt.Errorf("Unexpected program fxMagic %q", h.FxMagic[:])
+	}
+	p.setProgramName(string(name))
+	return nil
+}
+
+// readBank reads bank preset body and applies it to plugin.
+func (p *Plugin) readBank(r *bytes.Reader, h presetHeader) error {
+	future := make([]byte, bankFutureLen)
+	if _, err := io.ReadFull(r, future); err != nil {
+		return ErrInvalidPreset
+	}
+
+	if string(h.FxMagic[:]) == fxbChunk {
+		chunk, err := readChunk(r)
+		if err != nil {
+			return err
+		}
+		p.setChunk(false, chunk)
+		return nil
+	}
+
+	// every program has at least header and name.
+	if h.Count < 0 || int64(h.Count)*(presetHeaderLen+programNameLen) > int64(r.Len()) {
+		return ErrInvalidPreset
+	}
+	numPrograms := p.numPrograms()
+	for i := 0; i < int(h.Count); i++ {
+		ph, err := p.readPresetHeader(r)
+		if err != nil {
+			return err
+		}
+		if i < numPrograms {
+			p.setProgram(i)
+		}
+		if err := p.readProgram(r, ph); err != nil {
+			return err
+		}
+	}
+	// current program is stored only since version 2.
+	current := 0
+	if h.Version >= bankVersion {
+		current = int(binary.BigEndian.Uint32(future))
+	}
+	p.setProgram(current)
+	return nil
+}
+
+// readChunk reads size-prefixed opaque chunk. Size can't exceed the rest of data.
+func readChunk(r *bytes.Reader) ([]byte, error) {
+	var size int32
+	if err := binary.Read(r, binary.BigEndian, &size); err != nil || size < 0 || int64(size) > int64(r.Len()) {
+		return nil, ErrInvalidPreset
+	}
+	chunk := make([]byte, size)
+	if _, err := io.ReadFull(r, chunk); err != nil {
+		return nil, ErrInvalidPreset
+	}
+	return chunk, nil
+}
+
+// fourCC converts plugin unique id into its 4-char representation.
+func fourCC(id int32) string {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, uint32(id))
+	return fmt.Sprintf("%q", b)
+}
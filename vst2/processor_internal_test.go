@@ -0,0 +1,180 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/dudk/vst2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/test"
+)
+
+func TestFaultedProcessor(t *testing.T) {
+	p := NewProcessor(nil, 512, 48000, 2)
+	p.SetFlushTail(true)
+	p.faulted = true
+	_, err := p.process(phono.EmptyBuffer(2, 512))
+	assert.Equal(t, ErrFaulted, err)
+	_, err = p.Drain("")
+	assert.Equal(t, phono.ErrEOP, err)
+	// faulted plugin isn't called.
+	assert.Nil(t, p.Flush(""))
+}
+
+func TestConformOutput(t *testing.T) {
+	in := phono.Buffer{[]float64{1, 1, 1}, []float64{1, 1, 1}}
+	out, ok := conformOutput(phono.Buffer{[]float64{2, 2, 2}, []float64{3, 3, 3}}, in)
+	assert.True(t, ok)
+	assert.Equal(t, phono.Buffer{[]float64{2, 2, 2}, []float64{3, 3, 3}}, out)
+
+	out, ok = conformOutput(phono.Buffer{[]float64{2, 2, 2}}, in)
+	assert.False(t, ok)
+	assert.Equal(t, phono.Buffer{[]float64{2, 2, 2}, []float64{0, 0, 0}}, out)
+
+	out, ok = conformOutput(phono.Buffer{[]float64{2, 2}, []float64{3, 3, 3, 3}, []float64{4, 4, 4}}, in)
+	assert.False(t, ok)
+	assert.Equal(t, phono.Buffer{[]float64{2, 2, 0}, []float64{3, 3, 3}}, out)
+}
+
+func TestSanitize(t *testing.T) {
+	b := [][]float64{{0.5, math.NaN()}, {math.Inf(1), math.Inf(-1)}}
+	assert.Equal(t, int64(3), sanitize(b))
+	assert.Equal(t, [][]float64{{0.5, 0}, {0, 0}}, b)
+
+	b32 := [][]float32{{float32(math.NaN()), 0.5}}
+	assert.Equal(t, int64(1), sanitizeFloat32(b32))
+	assert.Equal(t, [][]float32{{0, 0.5}}, b32)
+}
+
+func TestHandleOpcode(t *testing.T) {
+	p := NewProcessor(nil, 512, 48000, 2)
+	callback := p.callback()
+	assert.Equal(t, 1, callback(nil, vst2.AudioMasterSizeWindow, 640, 480, nil, 0))
+	assert.Equal(t, 0, callback(nil, vst2.AudioMasterUpdateDisplay, 0, 0, nil, 0))
+
+	p.HandleOpcode(vst2.AudioMasterUpdateDisplay, func(*vst2.Plugin, vst2.MasterOpcode, int64, int64, unsafe.Pointer, float64) int {
+		return 1
+	})
+	assert.Equal(t, 1, callback(nil, vst2.AudioMasterUpdateDisplay, 0, 0, nil, 0))
+	// built-in answers are still used for other opcodes.
+	assert.Equal(t, 48000, callback(nil, vst2.AudioMasterGetSampleRate, 0, 0, nil, 0))
+
+	p.HandleOpcode(vst2.AudioMasterUpdateDisplay, nil)
+	assert.Equal(t, 0, callback(nil, vst2.AudioMasterUpdateDisplay, 0, 0, nil, 0))
+}
+
+func TestAutomateCallback(t *testing.T) {
+	p := NewProcessor(nil, 512, 48000, 2)
+	callback := p.callback()
+	// change is acknowledged without callback.
+	assert.Equal(t, 1, callback(nil, vst2.AudioMasterAutomate, 1, 0, nil, 0.25))
+
+	var points []AutomationPoint
+	p.SetAutomateCallback(func(point AutomationPoint) {
+		points = append(points, point)
+	})
+	p.advance(512)
+	assert.Equal(t, 1, callback(nil, vst2.AudioMasterAutomate, 3, 0, nil, 0.5))
+	assert.Equal(t, []AutomationPoint{{Position: 512, Index: 3, Value: 0.5}}, points)
+
+	p.SetAutomateCallback(nil)
+	assert.Equal(t, 1, callback(nil, vst2.AudioMasterAutomate, 3, 0, nil, 0.75))
+	assert.Equal(t, 1, len(points))
+}
+
+func TestHostInfo(t *testing.T) {
+	callback := NewProcessor(nil, 512, 48000, 2).callback()
+	assert.Equal(t, vstVersion, callback(nil, vst2.AudioMasterVersion, 0, 0, nil, 0))
+	assert.Equal(t, 1, callback(nil, vst2.AudioMasterGetLanguage, 0, 0, nil, 0))
+
+	buf := make([]byte, 64)
+	for i := range buf {
+		buf[i] = 'x'
+	}
+	assert.Equal(t, 1, callback(nil, vst2.AudioMasterGetVendorString, 0, 0, unsafe.Pointer(&buf[0]), 0))
+	assert.Equal(t, "phono\x00", string(buf[:6]))
+	assert.Equal(t, 0, callback(nil, vst2.AudioMasterGetProductString, 0, 0, nil, 0))
+}
+
+func TestMeasureProcessing(t *testing.T) {
+	plugin, err := Open(test.Vst)
+	assert.Nil(t, err)
+	defer plugin.Close()
+	p := NewProcessor(plugin, 512, 48000, 2)
+	// 512 samples at 48 kHz last less than 11 ms.
+	p.measureProcessing(time.Now(), 512)
+	assert.Equal(t, int64(0), p.Overruns())
+	p.measureProcessing(time.Now().Add(-20*time.Millisecond), 512)
+	assert.Equal(t, int64(1), p.Overruns())
+	assert.True(t, p.MaxProcessTime() >= 20*time.Millisecond)
+
+	assert.Nil(t, p.Reset(""))
+	assert.Equal(t, int64(0), p.Overruns())
+	assert.Equal(t, time.Duration(0), p.MaxProcessTime())
+
+	p.SetMeasureTiming(true)
+	_, err = p.processPlugin(phono.EmptyBuffer(2, 512))
+	assert.Nil(t, err)
+	assert.True(t, p.MaxProcessTime() > 0)
+	assert.Nil(t, p.Flush(""))
+}
+
+func TestSharesStorage(t *testing.T) {
+	in := phono.EmptyBuffer(2, 4)
+	assert.True(t, sharesStorage(in, in))
+	assert.True(t, sharesStorage(phono.Buffer{in[1][2:]}, in))
+	assert.False(t, sharesStorage(phono.EmptyBuffer(2, 4), in))
+	assert.False(t, sharesStorage(phono.Buffer{{}}, in))
+	// channels of pooled buffer are adjacent, but don't overlap.
+	assert.False(t, sharesStorage(phono.Buffer{in[1]}, phono.Buffer{in[0]}))
+}
+
+func TestProcessorTimeout(t *testing.T) {
+	plugin, err := Open(test.Vst)
+	assert.Nil(t, err)
+	// test plugin calls host while it processes float32 buffers.
+	plugin.SetPrecision(Float32)
+	p := NewProcessor(plugin, 512, 44100, 2)
+	p.SetTimeout(20 * time.Millisecond)
+	release := make(chan struct{})
+	defer close(release)
+	p.HandleOpcode(vst2.AudioMasterGetTime, func(*vst2.Plugin, vst2.MasterOpcode, int64, int64, unsafe.Pointer, float64) int {
+		p.m.RLock()
+		processing := p.processing
+		p.m.RUnlock()
+		if processing {
+			<-release
+		}
+		return 0
+	})
+	fn, err := p.Process("")
+	assert.Nil(t, err)
+	_, err = fn(phono.EmptyBuffer(2, 512))
+	assert.True(t, errors.Is(err, ErrProcessFailed))
+
+	// stuck plugin doesn't block shutdown.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := fn(phono.EmptyBuffer(2, 512))
+		assert.Equal(t, ErrFaulted, err)
+		assert.Nil(t, p.Flush(""))
+		assert.Nil(t, p.Reset(""))
+		assert.Nil(t, plugin.Close())
+		_, err = p.Process("")
+		assert.Equal(t, ErrFaulted, err)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Faulted processor blocked shutdown")
+	}
+}
@@ -0,0 +1,108 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dudk/vst2"
+)
+
+// FileExtension returns extension of vst2 files on current platform.
+func FileExtension() string {
+	return vst2.Extension
+}
+
+// DefaultScanPaths returns default vst2 locations of current platform.
+// Paths are absolute, so they can be used without Scan.
+func DefaultScanPaths() []string {
+	defaults := vst2.DefaultScanPaths()
+	paths := make([]string, 0, len(defaults))
+	for _, path := range defaults {
+		// some default paths are defined with trailing spaces.
+		path, err := expandHome(strings.TrimSpace(path))
+		if err != nil {
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// Scan recursively walks paths and reads info of every plugin found.
+// Bundle of macOS is a single plugin, its content isn't scanned.
+// Plugins which fail to load don't stop the scan, their errors are
+// returned as ScanErrors. Paths which don't exist are skipped.
+func Scan(paths []string) ([]PluginInfo, error) {
+	var infos []PluginInfo
+	errs := ScanErrors{}
+	scan := func(path string) {
+		info, err := scanPlugin(path)
+		if err != nil {
+			// broken links and files removed during scan aren't plugins.
+			if !errors.Is(err, ErrNotFound) {
+				errs[path] = err
+			}
+			return
+		}
+		infos = append(infos, info)
+	}
+	for _, root := range paths {
+		root, err := expandHome(root)
+		if err != nil {
+			return nil, err
+		}
+		err = filepath.Walk(root, func(path string, fi os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) {
+					return nil
+				}
+				return err
+			}
+			matches := strings.EqualFold(filepath.Ext(path), FileExtension())
+			if fi.IsDir() {
+				// regular directories are walked, but content of bundle
+				// must not be scanned. Bundles are loaded only on macOS.
+				if !isBundle(path) {
+					return nil
+				}
+				if matches {
+					scan(path)
+				}
+				return filepath.SkipDir
+			}
+			if matches {
+				scan(path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	if len(errs) > 0 {
+		return infos, errs
+	}
+	return infos, nil
+}
+
+// scanPlugin opens plugin, reads its info and closes it.
+func scanPlugin(path string) (info PluginInfo, err error) {
+	// misbehaving plugins must not stop the scan.
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%w: %v failed to load: %v", ErrInvalidPlugin, path, r)
+		}
+	}()
+	plugin, err := Open(path)
+	if err != nil {
+		return PluginInfo{}, err
+	}
+	defer plugin.Close()
+	return plugin.Info(), nil
+}
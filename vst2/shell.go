@@ -0,0 +1,173 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+/*
+#cgo CFLAGS: -std=gnu99
+
+#include "aeffectx.h"
+
+typedef AEffect *(*entryPoint)(audioMasterCallback host);
+
+VstIntPtr shellCallback(AEffect *effect, VstInt32 opcode, VstInt32 index, VstIntPtr value, void *ptr, float opt);
+
+static AEffect *loadShell(void *entry) {
+	return ((entryPoint)entry)((audioMasterCallback)shellCallback);
+}
+*/
+import "C"
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/dudk/vst2"
+)
+
+// ShellPlugin is a plugin hosted by shell plugin.
+type ShellPlugin struct {
+	ID   int
+	Name string
+}
+
+// shells holds plugins opened with OpenShell. vst2.Library answers
+// callbacks only for plugins it has created, but shell plugins ask for
+// requested id before creation. That's why such plugins get host
+// callback of this package which forwards calls to the plugin.
+var shells = struct {
+	sync.RWMutex
+	plugins map[*C.AEffect]*Plugin
+	opening sync.Mutex // serializes entry point calls.
+	id      int32      // id requested by plugin which is being opened.
+}{
+	plugins: make(map[*C.AEffect]*Plugin),
+}
+
+// OpenShell loads library and creates plugin with shellID hosted by shell
+// plugin in it. Library is closed when plugin is closed.
+func OpenShell(path string, shellID int) (*Plugin, error) {
+	lib, err := OpenLibrary(path)
+	if err != nil {
+		return nil, err
+	}
+	plugin, err := lib.OpenShell(shellID)
+	if err != nil {
+		lib.Close()
+		return nil, err
+	}
+	plugin.library = lib
+	return plugin, nil
+}
+
+// OpenShell creates new instance of plugin with shellID hosted by shell
+// plugin. Zero id opens shell plugin itself. Available ids are returned
+// by ShellPlugins.
+func (l *Library) OpenShell(shellID int) (plugin *Plugin, err error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+	if l.closed {
+		return nil, ErrClosed
+	}
+	shells.opening.Lock()
+	defer shells.opening.Unlock()
+	atomic.StoreInt32(&shells.id, int32(shellID))
+	defer atomic.StoreInt32(&shells.id, 0)
+
+	// vst2.Library doesn't export entry point, but it's the first field
+	// of the structure, the same way as AEffect of vst2.Plugin. Layout is
+	// verified by checkLayout when library is opened.
+	entry := *(*unsafe.Pointer)(unsafe.Pointer(l.lib))
+	effect := C.loadShell(entry)
+	if effect == nil {
+		return nil, fmt.Errorf("%w: %v failed to load shell id %d: entry point returned no effect", ErrInvalidPlugin, l.Path, shellID)
+	}
+	plugin = NewPlugin(newShellEffect(l.lib, effect))
+	plugin.shellID = shellID
+	shells.Lock()
+	shells.plugins[effect] = plugin
+	shells.Unlock()
+	return plugin, nil
+}
+
+// ShellPlugins returns plugins hosted by shell plugin. It returns
+// ErrUnsupportedOpcode if library isn't a shell plugin.
+func (l *Library) ShellPlugins() ([]ShellPlugin, error) {
+	shell, err := l.OpenShell(0)
+	if err != nil {
+		return nil, err
+	}
+	defer shell.Close()
+	if shell.Category() != CategoryShell {
+		return nil, fmt.Errorf("%w: %v isn't a shell plugin", ErrUnsupportedOpcode, l.Path)
+	}
+	shell.m.Lock()
+	defer shell.m.Unlock()
+	var plugins []ShellPlugin
+	buf := make([]byte, maxStringLen)
+	for {
+		buf[0] = 0
+		id := shell.dispatch(vst2.EffShellGetNextPlugin, 0, 0, unsafe.Pointer(&buf[0]), 0)
+		if id == 0 {
+			return plugins, nil
+		}
+		plugins = append(plugins, ShellPlugin{
+			ID:   int(id),
+			Name: C.GoString((*C.char)(unsafe.Pointer(&buf[0]))),
+		})
+	}
+}
+
+// ShellID returns id of plugin hosted by shell plugin. It's zero if
+// plugin isn't opened with OpenShell or shell plugin itself is opened.
+func (p *Plugin) ShellID() int {
+	return p.shellID
+}
+
+// newShellEffect returns vst2.Plugin for effect created without vst2.Library.
+// vst2.Plugin keeps effect and time info in unexported fields, so they are
+// set with reflection after their types are verified by checkLayout.
+func newShellEffect(lib *vst2.Library, effect *C.AEffect) *vst2.Plugin {
+	p := &vst2.Plugin{
+		Name: lib.Name,
+		Path: lib.Path,
+	}
+	v := reflect.ValueOf(p).Elem()
+	field := v.FieldByName("effect")
+	reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().
+		Set(reflect.NewAt(field.Type().Elem(), unsafe.Pointer(effect)))
+	field = v.FieldByName("timeInfo")
+	reflect.NewAt(field.Type(), unsafe.Pointer(field.UnsafeAddr())).Elem().
+		Set(reflect.New(field.Type().Elem()))
+	return p
+}
+
+// closeShell removes plugin opened with OpenShell.
+func closeShell(effect *C.AEffect) {
+	shells.Lock()
+	delete(shells.plugins, effect)
+	shells.Unlock()
+}
+
+// shellCallback is a host callback of plugins opened with OpenShell.
+//
+//export shellCallback
+func shellCallback(effect *C.AEffect, opcode C.VstInt32, index C.VstInt32, value C.VstIntPtr, ptr unsafe.Pointer, opt C.float) C.VstIntPtr {
+	shells.RLock()
+	plugin, ok := shells.plugins[effect]
+	shells.RUnlock()
+	if !ok {
+		// plugin is being opened.
+		if vst2.MasterOpcode(opcode) == vst2.AudioMasterCurrentID {
+			return C.VstIntPtr(atomic.LoadInt32(&shells.id))
+		}
+		return C.VstIntPtr(hostCallback(nil, vst2.MasterOpcode(opcode), int64(index), int64(value), ptr, float64(opt)))
+	}
+	if vst2.MasterOpcode(opcode) == vst2.AudioMasterCurrentID {
+		return C.VstIntPtr(plugin.shellID)
+	}
+	return C.VstIntPtr(plugin.forwardCallback(plugin.Plugin, vst2.MasterOpcode(opcode), int64(index), int64(value), ptr, float64(opt)))
+}
@@ -0,0 +1,607 @@
+//go:build !cgo || novst2
+// +build !cgo novst2
+
+package vst2
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"runtime"
+	"time"
+	"unsafe"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/log"
+)
+
+// ErrNotCompiled is returned by every entry point if package is built without
+// cgo or with novst2 tag. Such build provides the same API, so packages which
+// import vst2 compile and run without plugins. The only exception is API which
+// exposes types of github.com/dudk/vst2: embedded vst2.Plugin, host callbacks,
+// opcode handlers, time signatures and time info providers.
+var ErrNotCompiled = errors.New("Vst2 support is not compiled in")
+
+// Library is a loaded plugin binary. It can't be loaded in this build.
+type Library struct {
+	Path string
+}
+
+// Plugin is a vst2 plugin. It can't be opened in this build.
+type Plugin struct{}
+
+// ShellPlugin is a plugin hosted by shell plugin.
+type ShellPlugin struct {
+	ID   int
+	Name string
+}
+
+// PluginInfo describes plugin.
+type PluginInfo struct {
+	Path       string
+	Name       string
+	Vendor     string
+	Product    string
+	Version    int // vendor-specific version.
+	VSTVersion int // version of VST SDK, e.g. 2400 for 2.4.
+	UniqueID   int32
+	NumInputs  int
+	NumOutputs int
+	IsSynth    bool // true for instruments, false for effects.
+	Category   PluginCategory
+	Flags      int32 // raw effect flags.
+
+	// Capabilities derived from flags.
+	HasEditor          bool
+	ProgramsAreChunks  bool // state is stored in chunks instead of parameters.
+	CanReplacing       bool // plugin processes float32.
+	CanDoubleReplacing bool // plugin processes float64.
+}
+
+// PluginCategory is a category reported by plugin.
+type PluginCategory int
+
+// Plugin categories. Values are the same as in VST SDK.
+const (
+	CategoryUnknown PluginCategory = iota
+	CategoryEffect
+	CategorySynth
+	CategoryAnalysis
+	CategoryMastering
+	CategorySpatializer
+	CategoryRoomFx
+	CategorySurroundFx
+	CategoryRestoration
+	CategoryOfflineProcess
+	CategoryShell
+	CategoryGenerator
+)
+
+var categoryNames = map[PluginCategory]string{
+	CategoryUnknown:        "Unknown",
+	CategoryEffect:         "Effect",
+	CategorySynth:          "Synth",
+	CategoryAnalysis:       "Analysis",
+	CategoryMastering:      "Mastering",
+	CategorySpatializer:    "Spatializer",
+	CategoryRoomFx:         "RoomFx",
+	CategorySurroundFx:     "SurroundFx",
+	CategoryRestoration:    "Restoration",
+	CategoryOfflineProcess: "OfflineProcess",
+	CategoryShell:          "Shell",
+	CategoryGenerator:      "Generator",
+}
+
+// String returns name of category.
+func (c PluginCategory) String() string {
+	if name, ok := categoryNames[c]; ok {
+		return name
+	}
+	return fmt.Sprintf("PluginCategory(%d)", int(c))
+}
+
+// Precision defines sample type used to process buffers.
+type Precision int
+
+const (
+	// Auto processes float32 if plugin supports it and float64 otherwise.
+	Auto Precision = iota
+	// Float32 is used if plugin supports it.
+	Float32
+	// Float64 is used if plugin supports it.
+	Float64
+)
+
+// InfiniteTail is returned by TailSize if plugin's tail never ends, e.g. infinite reverb.
+const InfiniteTail = math.MaxInt32
+
+const (
+	// BankExtension is a file extension of bank presets.
+	BankExtension = ".fxb"
+	// ProgramExtension is a file extension of program presets.
+	ProgramExtension = ".fxp"
+)
+
+// MIDIEvent is a MIDI message which is sent to plugin.
+type MIDIEvent struct {
+	DeltaFrames int     // offset in samples from the start of buffer.
+	Data        [3]byte // MIDI message: status byte and two data bytes.
+}
+
+// FileExtension returns extension of vst2 files on current platform.
+func FileExtension() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return ".vst"
+	case "windows":
+		return ".dll"
+	}
+	return ".so"
+}
+
+// DefaultScanPaths returns nil.
+func DefaultScanPaths() []string {
+	return nil
+}
+
+// Scan returns ErrNotCompiled.
+func Scan(paths []string) ([]PluginInfo, error) {
+	return nil, ErrNotCompiled
+}
+
+// OpenLibrary returns ErrNotCompiled.
+func OpenLibrary(path string) (*Library, error) {
+	return nil, ErrNotCompiled
+}
+
+// Open returns ErrNotCompiled.
+func (l *Library) Open() (*Plugin, error) {
+	return nil, ErrNotCompiled
+}
+
+// OpenShell returns ErrNotCompiled.
+func OpenShell(path string, shellID int) (*Plugin, error) {
+	return nil, ErrNotCompiled
+}
+
+// OpenShell returns ErrNotCompiled.
+func (l *Library) OpenShell(shellID int) (*Plugin, error) {
+	return nil, ErrNotCompiled
+}
+
+// ShellPlugins returns ErrNotCompiled.
+func (l *Library) ShellPlugins() ([]ShellPlugin, error) {
+	return nil, ErrNotCompiled
+}
+
+// Close is no-op.
+func (l *Library) Close() error {
+	return nil
+}
+
+// Open returns ErrNotCompiled.
+func Open(path string) (*Plugin, error) {
+	return nil, ErrNotCompiled
+}
+
+// Close is no-op.
+func (p *Plugin) Close() error {
+	return nil
+}
+
+// Info returns empty info.
+func (p *Plugin) Info() PluginInfo {
+	return PluginInfo{}
+}
+
+// Category returns CategoryUnknown.
+func (p *Plugin) Category() PluginCategory {
+	return CategoryUnknown
+}
+
+// IsSynth returns false.
+func (p *Plugin) IsSynth() bool {
+	return false
+}
+
+// CanDo returns false.
+func (p *Plugin) CanDo(feature string) bool {
+	return false
+}
+
+// ShellID returns zero.
+func (p *Plugin) ShellID() int {
+	return 0
+}
+
+// NumInputs returns zero.
+func (p *Plugin) NumInputs() int {
+	return 0
+}
+
+// NumOutputs returns zero.
+func (p *Plugin) NumOutputs() int {
+	return 0
+}
+
+// NumParameters returns zero.
+func (p *Plugin) NumParameters() int {
+	return 0
+}
+
+// GetParameter returns zero.
+func (p *Plugin) GetParameter(index int) float32 {
+	return 0
+}
+
+// SetParameter is no-op.
+func (p *Plugin) SetParameter(index int, value float32) {}
+
+// ParameterName returns empty string.
+func (p *Plugin) ParameterName(index int) string {
+	return ""
+}
+
+// ParameterLabel returns empty string.
+func (p *Plugin) ParameterLabel(index int) string {
+	return ""
+}
+
+// ParameterByName returns false.
+func (p *Plugin) ParameterByName(name string) (index int, ok bool) {
+	return 0, false
+}
+
+// SetParameterByName returns false.
+func (p *Plugin) SetParameterByName(name string, value float32) bool {
+	return false
+}
+
+// NumPrograms returns zero.
+func (p *Plugin) NumPrograms() int {
+	return 0
+}
+
+// CurrentProgram returns zero.
+func (p *Plugin) CurrentProgram() int {
+	return 0
+}
+
+// SetProgram is no-op.
+func (p *Plugin) SetProgram(index int) {}
+
+// ProgramName returns empty string.
+func (p *Plugin) ProgramName() string {
+	return ""
+}
+
+// GetState returns ErrNotCompiled.
+func (p *Plugin) GetState() ([]byte, error) {
+	return nil, ErrNotCompiled
+}
+
+// SetState returns ErrNotCompiled.
+func (p *Plugin) SetState(data []byte) error {
+	return ErrNotCompiled
+}
+
+// LoadPreset returns ErrNotCompiled.
+func (p *Plugin) LoadPreset(path string) error {
+	return ErrNotCompiled
+}
+
+// SavePreset returns ErrNotCompiled.
+func (p *Plugin) SavePreset(path string) error {
+	return ErrNotCompiled
+}
+
+// InitialDelay returns zero.
+func (p *Plugin) InitialDelay() int {
+	return 0
+}
+
+// TailSize returns zero.
+func (p *Plugin) TailSize() int {
+	return 0
+}
+
+// SetBypass returns false.
+func (p *Plugin) SetBypass(bypass bool) bool {
+	return false
+}
+
+// SetSpeakerArrangement returns false.
+func (p *Plugin) SetSpeakerArrangement(numInputs, numOutputs int) bool {
+	return false
+}
+
+// PrefersFloat32 returns false.
+func (p *Plugin) PrefersFloat32() bool {
+	return false
+}
+
+// SetPrecision is no-op.
+func (p *Plugin) SetPrecision(precision Precision) {}
+
+// SetSanitize is no-op.
+func (p *Plugin) SetSanitize(sanitize bool) {}
+
+// Sanitized returns zero.
+func (p *Plugin) Sanitized() int64 {
+	return 0
+}
+
+// SetBufferSize is no-op.
+func (p *Plugin) SetBufferSize(bufferSize int) {}
+
+// SetSampleRate is no-op.
+func (p *Plugin) SetSampleRate(sampleRate int) {}
+
+// Resume is no-op.
+func (p *Plugin) Resume() {}
+
+// Suspend is no-op.
+func (p *Plugin) Suspend() {}
+
+// StartProcess is no-op.
+func (p *Plugin) StartProcess() {}
+
+// StopProcess is no-op.
+func (p *Plugin) StopProcess() {}
+
+// Process returns nil.
+func (p *Plugin) Process(b [][]float64) [][]float64 {
+	return nil
+}
+
+// ProcessFloat32 returns nil.
+func (p *Plugin) ProcessFloat32(b [][]float32) [][]float32 {
+	return nil
+}
+
+// ProcessMIDI is no-op.
+func (p *Plugin) ProcessMIDI(events []MIDIEvent) {}
+
+// HasEditor returns false.
+func (p *Plugin) HasEditor() bool {
+	return false
+}
+
+// OpenEditor returns ErrNotCompiled.
+func (p *Plugin) OpenEditor(parent unsafe.Pointer) error {
+	return ErrNotCompiled
+}
+
+// CloseEditor is no-op.
+func (p *Plugin) CloseEditor() {}
+
+// EditorOpen returns false.
+func (p *Plugin) EditorOpen() bool {
+	return false
+}
+
+// EditorRect returns zero size.
+func (p *Plugin) EditorRect() (width, height int) {
+	return 0, 0
+}
+
+// Processor is a vst2 processor. It returns ErrNotCompiled when pipe is started.
+type Processor struct {
+	phono.UID
+}
+
+// NewProcessor creates processor which can't process buffers.
+func NewProcessor(plugin *Plugin, bufferSize phono.BufferSize, sampleRate phono.SampleRate, numChannels phono.NumChannels) *Processor {
+	return &Processor{
+		UID: phono.NewUID(),
+	}
+}
+
+// Process returns ErrNotCompiled.
+func (p *Processor) Process(string) (phono.ProcessFunc, error) {
+	return nil, ErrNotCompiled
+}
+
+// Flush is no-op.
+func (p *Processor) Flush(string) error {
+	return nil
+}
+
+// Drain returns phono.ErrEOP.
+func (p *Processor) Drain(string) (phono.Buffer, error) {
+	return nil, phono.ErrEOP
+}
+
+// Reset is no-op.
+func (p *Processor) Reset(string) error {
+	return nil
+}
+
+// ChannelBuffer returns zero.
+func (p *Processor) ChannelBuffer() int {
+	return 0
+}
+
+// SetChannelBuffer is no-op.
+func (p *Processor) SetChannelBuffer(n int) {}
+
+// InitialDelay returns zero.
+func (p *Processor) InitialDelay() int {
+	return 0
+}
+
+// SampleRateParam returns param which does nothing.
+func (p *Processor) SampleRateParam(sampleRate phono.SampleRate) phono.Param {
+	return phono.Param{ID: p.ID(), Apply: func() {}}
+}
+
+// TempoParam returns param which does nothing.
+func (p *Processor) TempoParam(tempo float32) phono.Param {
+	return phono.Param{ID: p.ID(), Apply: func() {}}
+}
+
+// SetTempo is no-op.
+func (p *Processor) SetTempo(tempo float64) {}
+
+// SetTimeSignature is no-op.
+func (p *Processor) SetTimeSignature(num, denom int) {}
+
+// SetPlaying is no-op.
+func (p *Processor) SetPlaying(playing bool) {}
+
+// SetRecording is no-op.
+func (p *Processor) SetRecording(recording bool) {}
+
+// SetLoop is no-op.
+func (p *Processor) SetLoop(start, end int64) {}
+
+// Position returns zero.
+func (p *Processor) Position() int64 {
+	return 0
+}
+
+// SetPosition is no-op.
+func (p *Processor) SetPosition(position int64) {}
+
+// SetPositionCallback is no-op.
+func (p *Processor) SetPositionCallback(fn func(position int64)) {}
+
+// SetProgressCallback is no-op.
+func (p *Processor) SetProgressCallback(total int64, fn func(position int64, fraction float64)) {}
+
+// Automate is no-op.
+func (p *Processor) Automate(points ...AutomationPoint) {}
+
+// SetAutomateCallback is no-op.
+func (p *Processor) SetAutomateCallback(fn func(AutomationPoint)) {}
+
+// SendMIDI is no-op.
+func (p *Processor) SendMIDI(events []MIDIEvent) {}
+
+// SetBypass is no-op.
+func (p *Processor) SetBypass(bypass bool) {}
+
+// SetCrossfade is no-op.
+func (p *Processor) SetCrossfade(d time.Duration) {}
+
+// SetFlushTail is no-op.
+func (p *Processor) SetFlushTail(flushTail bool) {}
+
+// SetOffline is no-op.
+func (p *Processor) SetOffline(offline bool) {}
+
+// SetRecycle is no-op.
+func (p *Processor) SetRecycle(recycle bool) {}
+
+// SetLogger is no-op.
+func (p *Processor) SetLogger(l log.Logger) {}
+
+// SetTimeout is no-op.
+func (p *Processor) SetTimeout(timeout time.Duration) {}
+
+// SetMeasureTiming is no-op.
+func (p *Processor) SetMeasureTiming(measure bool) {}
+
+// MaxProcessTime returns zero.
+func (p *Processor) MaxProcessTime() time.Duration {
+	return 0
+}
+
+// Overruns returns zero.
+func (p *Processor) Overruns() int64 {
+	return 0
+}
+
+// Chain is a sequence of vst2 processors. It returns ErrNotCompiled when
+// pipe is started.
+type Chain struct {
+	phono.UID
+}
+
+// NewChain creates chain which can't process buffers.
+func NewChain(plugins []*Plugin, bufferSize phono.BufferSize, sampleRate phono.SampleRate, numChannels phono.NumChannels) *Chain {
+	return &Chain{
+		UID: phono.NewUID(),
+	}
+}
+
+// Process returns ErrNotCompiled.
+func (c *Chain) Process(sourceID string) (phono.ProcessFunc, error) {
+	return nil, ErrNotCompiled
+}
+
+// Flush is no-op.
+func (c *Chain) Flush(sourceID string) error {
+	return nil
+}
+
+// Drain returns phono.ErrEOP.
+func (c *Chain) Drain(sourceID string) (phono.Buffer, error) {
+	return nil, phono.ErrEOP
+}
+
+// Reset is no-op.
+func (c *Chain) Reset(sourceID string) error {
+	return nil
+}
+
+// Processor returns nil.
+func (c *Chain) Processor(index int) *Processor {
+	return nil
+}
+
+// InitialDelay returns zero.
+func (c *Chain) InitialDelay() int {
+	return 0
+}
+
+// SetBypass is no-op.
+func (c *Chain) SetBypass(index int, bypass bool) {}
+
+// SetFlushTail is no-op.
+func (c *Chain) SetFlushTail(flushTail bool) {}
+
+// Batch renders files through plugin. It returns ErrNotCompiled in this build.
+type Batch struct{}
+
+// NewBatch creates batch which can't render files.
+func NewBatch(plugin *Plugin, bufferSize phono.BufferSize) *Batch {
+	return &Batch{}
+}
+
+// Render returns ErrNotCompiled.
+func (b *Batch) Render(in, out string) error {
+	return ErrNotCompiled
+}
+
+// SetConcurrency is no-op.
+func (b *Batch) SetConcurrency(n int) {}
+
+// SetFlushTail is no-op.
+func (b *Batch) SetFlushTail(flushTail bool) {}
+
+// ProcessorPool keeps plugin instances. It can't be created in this build.
+type ProcessorPool struct{}
+
+// NewProcessorPool returns ErrNotCompiled.
+func NewProcessorPool(library *Library, size int) (*ProcessorPool, error) {
+	return nil, ErrNotCompiled
+}
+
+// Get returns ErrPoolClosed.
+func (pp *ProcessorPool) Get() (*Plugin, error) {
+	return nil, ErrPoolClosed
+}
+
+// Put is no-op.
+func (pp *ProcessorPool) Put(plugin *Plugin) {}
+
+// Size returns zero.
+func (pp *ProcessorPool) Size() int {
+	return 0
+}
+
+// Close is no-op.
+func (pp *ProcessorPool) Close() error {
+	return nil
+}
@@ -0,0 +1,198 @@
+//go:build !cgo || novst2
+// +build !cgo novst2
+
+package vst2_test
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/log"
+	"github.com/dudk/phono/vst2"
+)
+
+// API of vst2 package which must be provided by stub. Signatures are the
+// same as in cgo build, so missing or changed stubs fail to compile.
+var (
+	_ func() []string                                                                           = vst2.DefaultScanPaths
+	_ func() string                                                                             = vst2.FileExtension
+	_ func(*vst2.Plugin, phono.BufferSize) *vst2.Batch                                          = vst2.NewBatch
+	_ func(*vst2.Batch, string, string) error                                                   = (*vst2.Batch).Render
+	_ func(*vst2.Batch, int)                                                                    = (*vst2.Batch).SetConcurrency
+	_ func(*vst2.Batch, bool)                                                                   = (*vst2.Batch).SetFlushTail
+	_ func(vst2.BatchErrors) string                                                             = vst2.BatchErrors.Error
+	_ func([]*vst2.Plugin, phono.BufferSize, phono.SampleRate, phono.NumChannels) *vst2.Chain   = vst2.NewChain
+	_ func(*vst2.Chain, string) (phono.Buffer, error)                                           = (*vst2.Chain).Drain
+	_ func(*vst2.Chain, string) error                                                           = (*vst2.Chain).Flush
+	_ func(*vst2.Chain) int                                                                     = (*vst2.Chain).InitialDelay
+	_ func(*vst2.Chain, string) (phono.ProcessFunc, error)                                      = (*vst2.Chain).Process
+	_ func(*vst2.Chain, int) *vst2.Processor                                                    = (*vst2.Chain).Processor
+	_ func(*vst2.Chain, string) error                                                           = (*vst2.Chain).Reset
+	_ func(*vst2.Chain, int, bool)                                                              = (*vst2.Chain).SetBypass
+	_ func(*vst2.Chain, bool)                                                                   = (*vst2.Chain).SetFlushTail
+	_ func(string) (*vst2.Library, error)                                                       = vst2.OpenLibrary
+	_ func(*vst2.Library) error                                                                 = (*vst2.Library).Close
+	_ func(*vst2.Library) (*vst2.Plugin, error)                                                 = (*vst2.Library).Open
+	_ func(*vst2.Library, int) (*vst2.Plugin, error)                                            = (*vst2.Library).OpenShell
+	_ func(*vst2.Library) ([]vst2.ShellPlugin, error)                                           = (*vst2.Library).ShellPlugins
+	_ func(string) (*vst2.Plugin, error)                                                        = vst2.Open
+	_ func(string, int) (*vst2.Plugin, error)                                                   = vst2.OpenShell
+	_ func(*vst2.Plugin, string) bool                                                           = (*vst2.Plugin).CanDo
+	_ func(*vst2.Plugin) vst2.PluginCategory                                                    = (*vst2.Plugin).Category
+	_ func(*vst2.Plugin) error                                                                  = (*vst2.Plugin).Close
+	_ func(*vst2.Plugin)                                                                        = (*vst2.Plugin).CloseEditor
+	_ func(*vst2.Plugin) int                                                                    = (*vst2.Plugin).CurrentProgram
+	_ func(*vst2.Plugin) bool                                                                   = (*vst2.Plugin).EditorOpen
+	_ func(*vst2.Plugin) (int, int)                                                             = (*vst2.Plugin).EditorRect
+	_ func(*vst2.Plugin, int) float32                                                           = (*vst2.Plugin).GetParameter
+	_ func(*vst2.Plugin) ([]byte, error)                                                        = (*vst2.Plugin).GetState
+	_ func(*vst2.Plugin) bool                                                                   = (*vst2.Plugin).HasEditor
+	_ func(*vst2.Plugin) vst2.PluginInfo                                                        = (*vst2.Plugin).Info
+	_ func(*vst2.Plugin) int                                                                    = (*vst2.Plugin).InitialDelay
+	_ func(*vst2.Plugin) bool                                                                   = (*vst2.Plugin).IsSynth
+	_ func(*vst2.Plugin, string) error                                                          = (*vst2.Plugin).LoadPreset
+	_ func(*vst2.Plugin) int                                                                    = (*vst2.Plugin).NumInputs
+	_ func(*vst2.Plugin) int                                                                    = (*vst2.Plugin).NumOutputs
+	_ func(*vst2.Plugin) int                                                                    = (*vst2.Plugin).NumParameters
+	_ func(*vst2.Plugin) int                                                                    = (*vst2.Plugin).NumPrograms
+	_ func(*vst2.Plugin, unsafe.Pointer) error                                                  = (*vst2.Plugin).OpenEditor
+	_ func(*vst2.Plugin, string) (int, bool)                                                    = (*vst2.Plugin).ParameterByName
+	_ func(*vst2.Plugin, int) string                                                            = (*vst2.Plugin).ParameterLabel
+	_ func(*vst2.Plugin, int) string                                                            = (*vst2.Plugin).ParameterName
+	_ func(*vst2.Plugin) bool                                                                   = (*vst2.Plugin).PrefersFloat32
+	_ func(*vst2.Plugin, [][]float64) [][]float64                                               = (*vst2.Plugin).Process
+	_ func(*vst2.Plugin, [][]float32) [][]float32                                               = (*vst2.Plugin).ProcessFloat32
+	_ func(*vst2.Plugin, []vst2.MIDIEvent)                                                      = (*vst2.Plugin).ProcessMIDI
+	_ func(*vst2.Plugin) string                                                                 = (*vst2.Plugin).ProgramName
+	_ func(*vst2.Plugin)                                                                        = (*vst2.Plugin).Resume
+	_ func(*vst2.Plugin) int64                                                                  = (*vst2.Plugin).Sanitized
+	_ func(*vst2.Plugin, string) error                                                          = (*vst2.Plugin).SavePreset
+	_ func(*vst2.Plugin, int)                                                                   = (*vst2.Plugin).SetBufferSize
+	_ func(*vst2.Plugin, bool) bool                                                             = (*vst2.Plugin).SetBypass
+	_ func(*vst2.Plugin, int, float32)                                                          = (*vst2.Plugin).SetParameter
+	_ func(*vst2.Plugin, string, float32) bool                                                  = (*vst2.Plugin).SetParameterByName
+	_ func(*vst2.Plugin, vst2.Precision)                                                        = (*vst2.Plugin).SetPrecision
+	_ func(*vst2.Plugin, int)                                                                   = (*vst2.Plugin).SetProgram
+	_ func(*vst2.Plugin, int)                                                                   = (*vst2.Plugin).SetSampleRate
+	_ func(*vst2.Plugin, bool)                                                                  = (*vst2.Plugin).SetSanitize
+	_ func(*vst2.Plugin, int, int) bool                                                         = (*vst2.Plugin).SetSpeakerArrangement
+	_ func(*vst2.Plugin, []byte) error                                                          = (*vst2.Plugin).SetState
+	_ func(*vst2.Plugin) int                                                                    = (*vst2.Plugin).ShellID
+	_ func(*vst2.Plugin)                                                                        = (*vst2.Plugin).StartProcess
+	_ func(*vst2.Plugin)                                                                        = (*vst2.Plugin).StopProcess
+	_ func(*vst2.Plugin)                                                                        = (*vst2.Plugin).Suspend
+	_ func(*vst2.Plugin) int                                                                    = (*vst2.Plugin).TailSize
+	_ func(vst2.PluginCategory) string                                                          = vst2.PluginCategory.String
+	_ func([]string) ([]vst2.PluginInfo, error)                                                 = vst2.Scan
+	_ func(*vst2.Plugin, phono.BufferSize, phono.SampleRate, phono.NumChannels) *vst2.Processor = vst2.NewProcessor
+	_ func(*vst2.Processor, ...vst2.AutomationPoint)                                            = (*vst2.Processor).Automate
+	_ func(*vst2.Processor) int                                                                 = (*vst2.Processor).ChannelBuffer
+	_ func(*vst2.Processor, string) (phono.Buffer, error)                                       = (*vst2.Processor).Drain
+	_ func(*vst2.Processor, string) error                                                       = (*vst2.Processor).Flush
+	_ func(*vst2.Processor) int                                                                 = (*vst2.Processor).InitialDelay
+	_ func(*vst2.Processor) time.Duration                                                       = (*vst2.Processor).MaxProcessTime
+	_ func(*vst2.Processor) int64                                                               = (*vst2.Processor).Overruns
+	_ func(*vst2.Processor) int64                                                               = (*vst2.Processor).Position
+	_ func(*vst2.Processor, string) (phono.ProcessFunc, error)                                  = (*vst2.Processor).Process
+	_ func(*vst2.Processor, string) error                                                       = (*vst2.Processor).Reset
+	_ func(*vst2.Processor, phono.SampleRate) phono.Param                                       = (*vst2.Processor).SampleRateParam
+	_ func(*vst2.Processor, []vst2.MIDIEvent)                                                   = (*vst2.Processor).SendMIDI
+	_ func(*vst2.Processor, func(vst2.AutomationPoint))                                         = (*vst2.Processor).SetAutomateCallback
+	_ func(*vst2.Processor, bool)                                                               = (*vst2.Processor).SetBypass
+	_ func(*vst2.Processor, int)                                                                = (*vst2.Processor).SetChannelBuffer
+	_ func(*vst2.Processor, time.Duration)                                                      = (*vst2.Processor).SetCrossfade
+	_ func(*vst2.Processor, bool)                                                               = (*vst2.Processor).SetFlushTail
+	_ func(*vst2.Processor, log.Logger)                                                         = (*vst2.Processor).SetLogger
+	_ func(*vst2.Processor, int64, int64)                                                       = (*vst2.Processor).SetLoop
+	_ func(*vst2.Processor, bool)                                                               = (*vst2.Processor).SetMeasureTiming
+	_ func(*vst2.Processor, bool)                                                               = (*vst2.Processor).SetOffline
+	_ func(*vst2.Processor, bool)                                                               = (*vst2.Processor).SetPlaying
+	_ func(*vst2.Processor, int64)                                                              = (*vst2.Processor).SetPosition
+	_ func(*vst2.Processor, func(int64))                                                        = (*vst2.Processor).SetPositionCallback
+	_ func(*vst2.Processor, int64, func(int64, float64))                                        = (*vst2.Processor).SetProgressCallback
+	_ func(*vst2.Processor, bool)                                                               = (*vst2.Processor).SetRecording
+	_ func(*vst2.Processor, bool)                                                               = (*vst2.Processor).SetRecycle
+	_ func(*vst2.Processor, float64)                                                            = (*vst2.Processor).SetTempo
+	_ func(*vst2.Processor, int, int)                                                           = (*vst2.Processor).SetTimeSignature
+	_ func(*vst2.Processor, time.Duration)                                                      = (*vst2.Processor).SetTimeout
+	_ func(*vst2.Processor, float32) phono.Param                                                = (*vst2.Processor).TempoParam
+	_ func(*vst2.Library, int) (*vst2.ProcessorPool, error)                                     = vst2.NewProcessorPool
+	_ func(*vst2.ProcessorPool) error                                                           = (*vst2.ProcessorPool).Close
+	_ func(*vst2.ProcessorPool) (*vst2.Plugin, error)                                           = (*vst2.ProcessorPool).Get
+	_ func(*vst2.ProcessorPool, *vst2.Plugin)                                                   = (*vst2.ProcessorPool).Put
+	_ func(*vst2.ProcessorPool) int                                                             = (*vst2.ProcessorPool).Size
+	_ func(vst2.ScanErrors) string                                                              = vst2.ScanErrors.Error
+
+	_ error = vst2.ErrClosed
+	_ error = vst2.ErrFaulted
+	_ error = vst2.ErrInvalidPreset
+	_ error = vst2.ErrNoChunks
+	_ error = vst2.ErrNoEditor
+	_ error = vst2.ErrNoWindow
+	_ error = vst2.ErrPoolClosed
+	_ error = vst2.ErrProcessing
+	_ error = vst2.BatchErrors{}
+	_ error = vst2.ScanErrors{}
+
+	_ = []vst2.PluginCategory{
+		vst2.CategoryUnknown,
+		vst2.CategoryEffect,
+		vst2.CategorySynth,
+		vst2.CategoryAnalysis,
+		vst2.CategoryMastering,
+		vst2.CategorySpatializer,
+		vst2.CategoryRoomFx,
+		vst2.CategorySurroundFx,
+		vst2.CategoryRestoration,
+		vst2.CategoryOfflineProcess,
+		vst2.CategoryShell,
+		vst2.CategoryGenerator,
+	}
+	_     = []vst2.Precision{vst2.Auto, vst2.Float32, vst2.Float64}
+	_     = []string{vst2.BankExtension, vst2.ProgramExtension}
+	_ int = vst2.InfiniteTail
+
+	_ = vst2.ShellPlugin{ID: 0, Name: ""}
+	_ = vst2.MIDIEvent{DeltaFrames: 0, Data: [3]byte{}}
+	_ = vst2.AutomationPoint{Position: 0, Index: 0, Value: 0}
+	_ = vst2.Library{Path: ""}
+	_ = vst2.PluginInfo{
+		Path:               "",
+		Name:               "",
+		Vendor:             "",
+		Product:            "",
+		Version:            0,
+		VSTVersion:         0,
+		UniqueID:           0,
+		NumInputs:          0,
+		NumOutputs:         0,
+		IsSynth:            false,
+		Category:           vst2.CategoryUnknown,
+		Flags:              0,
+		HasEditor:          false,
+		ProgramsAreChunks:  false,
+		CanReplacing:       false,
+		CanDoubleReplacing: false,
+	}
+)
+
+func TestStub(t *testing.T) {
+	_, err := vst2.Open("plugin.vst")
+	assert.Equal(t, vst2.ErrNotCompiled, err)
+	_, err = vst2.OpenLibrary("plugin.vst")
+	assert.Equal(t, vst2.ErrNotCompiled, err)
+	_, err = vst2.NewProcessor(nil, 512, 44100, 2).Process("")
+	assert.Equal(t, vst2.ErrNotCompiled, err)
+	_, err = vst2.Scan(vst2.DefaultScanPaths())
+	assert.Equal(t, vst2.ErrNotCompiled, err)
+	_, err = vst2.NewChain(nil, 512, 44100, 2).Process("")
+	assert.Equal(t, vst2.ErrNotCompiled, err)
+	assert.Equal(t, vst2.ErrNotCompiled, vst2.NewBatch(nil, 512).Render("in", "out"))
+	_, err = vst2.NewProcessorPool(nil, 1)
+	assert.Equal(t, vst2.ErrNotCompiled, err)
+	assert.Equal(t, "Shell", vst2.CategoryShell.String())
+}
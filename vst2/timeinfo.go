@@ -0,0 +1,144 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+import (
+	"math"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/vst2"
+)
+
+// TimePosition is a position of processor passed to TimeInfoProvider.
+type TimePosition struct {
+	SamplePos     int64
+	SampleRate    phono.SampleRate
+	Tempo         float32
+	TimeSignature vst2.TimeSignature
+	PPQ           float64 // position in quarter notes from the start.
+	SignaturePPQ  float64 // ppq position of the first bar with current time signature.
+}
+
+// TimeInfo contains timing values reported to plugin in VstTimeInfo.
+type TimeInfo struct {
+	SamplePos     int64
+	Tempo         float32
+	TimeSignature vst2.TimeSignature
+	PPQPos        float64
+	BarPos        float64 // ppq position of the last bar start.
+}
+
+// TimeInfoProvider returns time info reported to plugin for position. It's
+// called from host callback, so it must not block.
+type TimeInfoProvider func(TimePosition) TimeInfo
+
+// DefaultTimeInfo is a time info provider used by processor by default.
+// Positions are 0-based as defined by VST2: the first sample has ppq 0
+// and bar start is a ppq position of the last complete bar.
+func DefaultTimeInfo(pos TimePosition) TimeInfo {
+	info := TimeInfo{
+		SamplePos:     pos.SamplePos,
+		Tempo:         pos.Tempo,
+		TimeSignature: pos.TimeSignature,
+		PPQPos:        pos.PPQ,
+	}
+	info.BarPos = barStart(pos.PPQ, pos.SignaturePPQ, pos.TimeSignature)
+	return info
+}
+
+// barStart returns ppq position of bar which contains ppq. Bars are
+// counted from anchor, where time signature was changed.
+func barStart(ppq, anchor float64, ts vst2.TimeSignature) float64 {
+	if ts.NotesPerBar <= 0 || ts.NoteValue <= 0 {
+		return anchor
+	}
+	// length of bar in quarter notes, e.g. 3 for 6/8.
+	barLength := float64(ts.NotesPerBar) * 4 / float64(ts.NoteValue)
+	return anchor + math.Floor((ppq-anchor)/barLength)*barLength
+}
+
+// SetTimeInfoProvider sets function which calculates time info reported to
+// plugin, so ppq and bar positions can follow conventions expected by plugin.
+// Nil provider restores DefaultTimeInfo.
+func (p *Processor) SetTimeInfoProvider(provider TimeInfoProvider) {
+	p.m.Lock()
+	p.timeInfoProvider = provider
+	p.m.Unlock()
+}
+
+// timingChange is a tempo and time signature set while processor is
+// running. Zero values mean that the value isn't changed.
+type timingChange struct {
+	tempo         float32
+	timeSignature vst2.TimeSignature
+}
+
+// SetTempo changes tempo reported to plugin. It's safe to call SetTempo
+// while processor is running, change is applied at the next buffer and
+// plugin is told that transport changed. Non-positive tempo is ignored.
+func (p *Processor) SetTempo(tempo float64) {
+	if tempo <= 0 {
+		return
+	}
+	p.m.Lock()
+	p.timingChange.tempo = float32(tempo)
+	p.m.Unlock()
+}
+
+// SetTimeSignature changes time signature reported to plugin, e.g. 6 and 8
+// for 6/8. It's applied the same way as SetTempo. Non-positive values are
+// ignored.
+func (p *Processor) SetTimeSignature(num, denom int) {
+	if num <= 0 || denom <= 0 {
+		return
+	}
+	p.m.Lock()
+	p.timingChange.timeSignature = vst2.TimeSignature{NotesPerBar: num, NoteValue: denom}
+	p.m.Unlock()
+}
+
+// applyTimingChange applies tempo and time signature set since the last buffer.
+func (p *Processor) applyTimingChange() {
+	p.m.Lock()
+	defer p.m.Unlock()
+	change := p.timingChange
+	p.timingChange = timingChange{}
+	if change.tempo > 0 && change.tempo != p.tempo {
+		p.setTempo(change.tempo)
+		p.transport.changed = true
+	}
+	if change.timeSignature.NotesPerBar > 0 && change.timeSignature != p.timeSignature {
+		p.setTimeSignature(change.timeSignature)
+		p.transport.changed = true
+	}
+}
+
+// setTimeSignature changes time signature at current position. The bar
+// which contains current position is started again with new signature,
+// so bar position doesn't jump. Caller must hold the lock.
+func (p *Processor) setTimeSignature(timeSignature vst2.TimeSignature) {
+	p.signaturePPQ = barStart(p.ppq(p.currentPosition), p.signaturePPQ, p.timeSignature)
+	p.timeSignature = timeSignature
+}
+
+// timing returns current position, tempo, time signature and
+// ppq positions of current sample and bar start.
+func (p *Processor) timing() (samplePos int64, tempo float32, timeSignature vst2.TimeSignature, ppqPos float64, barPos float64) {
+	p.m.RLock()
+	pos := TimePosition{
+		SamplePos:     p.currentPosition,
+		SampleRate:    p.sampleRate,
+		Tempo:         p.tempo,
+		TimeSignature: p.timeSignature,
+		PPQ:           p.ppq(p.currentPosition),
+		SignaturePPQ:  p.signaturePPQ,
+	}
+	provider := p.timeInfoProvider
+	p.m.RUnlock()
+	if provider == nil {
+		provider = DefaultTimeInfo
+	}
+	info := provider(pos)
+	return info.SamplePos, info.Tempo, info.TimeSignature, info.PPQPos, info.BarPos
+}
@@ -0,0 +1,256 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/dudk/vst2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTempoChange(t *testing.T) {
+	p := NewProcessor(nil, 512, 48000, 2)
+	// one beat at 120 bpm.
+	p.advance(24000)
+	_, _, _, before, _ := p.timing()
+	p.TempoParam(60).Apply()
+	_, tempo, _, after, _ := p.timing()
+	assert.Equal(t, float32(60), tempo)
+	assert.InDelta(t, before, after, 1e-9)
+
+	// one beat at 60 bpm.
+	p.advance(48000)
+	_, _, _, ppqPos, _ := p.timing()
+	assert.InDelta(t, before+1, ppqPos, 1e-9)
+}
+
+func TestSetTempo(t *testing.T) {
+	p := NewProcessor(nil, 512, 48000, 2)
+	p.reportTransport()
+	p.advance(24000)
+	p.SetTempo(60)
+	p.SetTimeSignature(3, 4)
+	p.SetTempo(-1)
+	p.SetTimeSignature(0, 4)
+	// change isn't applied until the next buffer.
+	_, tempo, timeSignature, before, _ := p.timing()
+	assert.Equal(t, float32(120), tempo)
+	assert.Equal(t, defaultTimeSignature, timeSignature)
+	assert.False(t, p.reportTransport().changed)
+
+	p.applyTimingChange()
+	_, tempo, timeSignature, after, _ := p.timing()
+	assert.Equal(t, float32(60), tempo)
+	assert.Equal(t, vst2.TimeSignature{NotesPerBar: 3, NoteValue: 4}, timeSignature)
+	assert.InDelta(t, before, after, 1e-9)
+	assert.True(t, p.reportTransport().changed)
+
+	// the same values aren't reported as change.
+	p.SetTempo(60)
+	p.applyTimingChange()
+	assert.False(t, p.reportTransport().changed)
+}
+
+func TestTimingParams(t *testing.T) {
+	p := NewProcessor(nil, 512, 48000, 2)
+	p.reportTransport()
+	// invalid values are ignored.
+	p.TempoParam(0).Apply()
+	p.TimeSignatureParam(vst2.TimeSignature{NotesPerBar: 0, NoteValue: 4}).Apply()
+	_, tempo, timeSignature, _, _ := p.timing()
+	assert.Equal(t, float32(120), tempo)
+	assert.Equal(t, defaultTimeSignature, timeSignature)
+	assert.False(t, p.reportTransport().changed)
+
+	p.TempoParam(60).Apply()
+	_, tempo, _, _, _ = p.timing()
+	assert.Equal(t, float32(60), tempo)
+	assert.True(t, p.reportTransport().changed)
+
+	// signature is changed in the middle of the second bar at 120 bpm.
+	p.TempoParam(120).Apply()
+	p.advance(144000)
+	p.TimeSignatureParam(vst2.TimeSignature{NotesPerBar: 3, NoteValue: 4}).Apply()
+	assert.True(t, p.reportTransport().changed)
+	_, _, _, ppqPos, barPos := p.timing()
+	assert.InDelta(t, 6.0, ppqPos, 1e-9)
+	assert.InDelta(t, 4.0, barPos, 1e-9)
+	// bars of new signature start from the changed bar.
+	p.advance(24000)
+	_, _, _, ppqPos, barPos = p.timing()
+	assert.InDelta(t, 7.0, ppqPos, 1e-9)
+	assert.InDelta(t, 7.0, barPos, 1e-9)
+}
+
+func TestOfflineProcessLevel(t *testing.T) {
+	p := NewProcessor(&Plugin{}, 512, 48000, 2)
+	assert.Equal(t, processLevelUser, p.processLevel())
+	p.setProcessing(true)
+	assert.Equal(t, processLevelRealtime, p.processLevel())
+	p.SetOffline(true)
+	assert.Equal(t, processLevelOffline, p.processLevel())
+	p.setProcessing(false)
+	assert.Equal(t, processLevelOffline, p.processLevel())
+}
+
+func TestEditorProcessLevel(t *testing.T) {
+	plugin := &Plugin{}
+	p := NewProcessor(plugin, 512, 48000, 2)
+	atomic.StoreInt32(&plugin.editorOpen, 1)
+	assert.True(t, plugin.EditorOpen())
+	assert.Equal(t, processLevelUser, p.processLevel())
+	p.setStreaming(true)
+	assert.Equal(t, processLevelRealtime, p.processLevel())
+	atomic.StoreInt32(&plugin.editorOpen, 0)
+	assert.Equal(t, processLevelUser, p.processLevel())
+}
+
+func TestPosition(t *testing.T) {
+	p := NewProcessor(nil, 512, 48000, 2)
+	var positions []int64
+	p.SetPositionCallback(func(position int64) {
+		positions = append(positions, position)
+	})
+	p.advance(512)
+	p.advance(256)
+	assert.Equal(t, int64(768), p.Position())
+	assert.Equal(t, []int64{512, 768}, positions)
+
+	p.SetPositionCallback(nil)
+	p.advance(512)
+	assert.Equal(t, int64(1280), p.Position())
+	assert.Equal(t, 2, len(positions))
+}
+
+func TestProgress(t *testing.T) {
+	p := NewProcessor(nil, 512, 48000, 2)
+	var fractions []float64
+	p.SetProgressCallback(1024, func(position int64, fraction float64) {
+		fractions = append(fractions, fraction)
+	})
+	p.advance(512)
+	p.advance(512)
+	// tail is longer than total.
+	p.advance(512)
+	assert.Equal(t, []float64{0.5, 1, 1}, fractions)
+
+	// unknown length.
+	var positions []int64
+	p.SetProgressCallback(0, func(position int64, fraction float64) {
+		positions = append(positions, position)
+		assert.Equal(t, float64(-1), fraction)
+	})
+	p.advance(512)
+	assert.Equal(t, []int64{2048}, positions)
+}
+
+func TestLoop(t *testing.T) {
+	p := NewProcessor(nil, 512, 48000, 2)
+	p.SetLoop(1000, 2000)
+	p.SetPosition(1500)
+	samplePos, _, _, ppqPos, _ := p.timing()
+	assert.Equal(t, int64(1500), samplePos)
+	p.advance(300)
+	assert.Equal(t, int64(1800), p.Position())
+	// position wraps at the end of loop.
+	p.advance(300)
+	assert.Equal(t, int64(1100), p.Position())
+	p.advance(400)
+	samplePos, _, _, looped, _ := p.timing()
+	assert.Equal(t, int64(1500), samplePos)
+	assert.InDelta(t, ppqPos, looped, 1e-9)
+
+	active, start, end := p.loopInfo()
+	assert.True(t, active)
+	assert.True(t, start < end)
+
+	p.SetLoop(0, 0)
+	active, _, _ = p.loopInfo()
+	assert.False(t, active)
+	p.advance(1000)
+	assert.Equal(t, int64(2500), p.Position())
+}
+
+func TestTransport(t *testing.T) {
+	p := NewProcessor(nil, 512, 48000, 2)
+	// start of transport is a change.
+	state := p.reportTransport()
+	assert.False(t, state.stopped)
+	assert.True(t, state.changed)
+	assert.False(t, p.reportTransport().changed)
+
+	p.SetPlaying(false)
+	p.SetRecording(true)
+	state = p.reportTransport()
+	assert.True(t, state.stopped)
+	assert.True(t, state.recording)
+	assert.True(t, state.changed)
+	// change is reported once.
+	assert.False(t, p.reportTransport().changed)
+
+	p.SetPlaying(false)
+	assert.False(t, p.reportTransport().changed)
+	p.SetPlaying(true)
+	state = p.reportTransport()
+	assert.False(t, state.stopped)
+	assert.True(t, state.changed)
+}
+
+func TestTimeInfoProvider(t *testing.T) {
+	p := NewProcessor(nil, 512, 48000, 2)
+	// two beats at 120 bpm.
+	p.advance(48000)
+	_, _, _, ppqPos, barPos := p.timing()
+	assert.InDelta(t, 2.0, ppqPos, 1e-9)
+	assert.Equal(t, 0.0, barPos)
+
+	var received TimePosition
+	p.SetTimeInfoProvider(func(pos TimePosition) TimeInfo {
+		received = pos
+		info := DefaultTimeInfo(pos)
+		info.PPQPos = pos.PPQ + 1
+		return info
+	})
+	samplePos, tempo, _, ppqPos, _ := p.timing()
+	assert.Equal(t, int64(48000), received.SamplePos)
+	assert.Equal(t, int64(48000), samplePos)
+	assert.Equal(t, float32(120), tempo)
+	assert.InDelta(t, 2.0, received.PPQ, 1e-9)
+	assert.InDelta(t, 3.0, ppqPos, 1e-9)
+
+	p.SetTimeInfoProvider(nil)
+	_, _, _, ppqPos, _ = p.timing()
+	assert.InDelta(t, 2.0, ppqPos, 1e-9)
+}
+
+func TestDefaultTimeInfo(t *testing.T) {
+	// 4/4 at 120 bpm and 48 kHz has 24000 samples per beat.
+	tests := []struct {
+		samplePos     int64
+		timeSignature vst2.TimeSignature
+		ppqPos        float64
+		barPos        float64
+	}{
+		{samplePos: 0, timeSignature: defaultTimeSignature, ppqPos: 0, barPos: 0},
+		{samplePos: 12000, timeSignature: defaultTimeSignature, ppqPos: 0.5, barPos: 0},
+		{samplePos: 24000, timeSignature: defaultTimeSignature, ppqPos: 1, barPos: 0},
+		{samplePos: 95999, timeSignature: defaultTimeSignature, ppqPos: 95999.0 / 24000, barPos: 0},
+		{samplePos: 96000, timeSignature: defaultTimeSignature, ppqPos: 4, barPos: 4},
+		{samplePos: 216000, timeSignature: defaultTimeSignature, ppqPos: 9, barPos: 8},
+		// 6/8 bar has 3 quarter notes.
+		{samplePos: 96000, timeSignature: vst2.TimeSignature{NotesPerBar: 6, NoteValue: 8}, ppqPos: 4, barPos: 3},
+	}
+	for _, test := range tests {
+		p := NewProcessor(nil, 512, 48000, 2)
+		p.TimeSignatureParam(test.timeSignature).Apply()
+		p.advance(test.samplePos)
+		samplePos, tempo, _, ppqPos, barPos := p.timing()
+		assert.Equal(t, test.samplePos, samplePos)
+		assert.Equal(t, float32(120), tempo)
+		assert.InDelta(t, test.ppqPos, ppqPos, 1e-9, "sample %v", test.samplePos)
+		assert.InDelta(t, test.barPos, barPos, 1e-9, "sample %v", test.samplePos)
+	}
+}
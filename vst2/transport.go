@@ -0,0 +1,142 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2
+
+/*
+#include "aeffectx.h"
+
+// setCycle updates loop fields of time info, pointer to which is passed as integer.
+static void setCycle(VstIntPtr ptr, int active, double start, double end) {
+	VstTimeInfo *info = (VstTimeInfo *)ptr;
+	if (active) {
+		info->flags |= kVstTransportCycleActive | kVstCyclePosValid;
+		info->cycleStartPos = start;
+		info->cycleEndPos = end;
+	} else {
+		info->flags &= ~(kVstTransportCycleActive | kVstCyclePosValid);
+	}
+}
+
+// setState updates transport state flags of time info.
+static void setState(VstIntPtr ptr, int playing, int recording, int changed) {
+	VstTimeInfo *info = (VstTimeInfo *)ptr;
+	VstInt32 flags = kVstTransportPlaying | kVstTransportRecording | kVstTransportChanged;
+	info->flags &= ~flags;
+	if (playing) {
+		info->flags |= kVstTransportPlaying;
+	}
+	if (recording) {
+		info->flags |= kVstTransportRecording;
+	}
+	if (changed) {
+		info->flags |= kVstTransportChanged;
+	}
+}
+*/
+import "C"
+
+// loopRegion is a region of looped playback in samples.
+type loopRegion struct {
+	start int64
+	end   int64
+}
+
+// active checks if region has length.
+func (l loopRegion) active() bool {
+	return l.end > l.start
+}
+
+// wrap moves position which passed the end of region back into region.
+func (l loopRegion) wrap(position int64) int64 {
+	if !l.active() || position < l.end {
+		return position
+	}
+	return l.start + (position-l.end)%(l.end-l.start)
+}
+
+// SetPosition moves current position, so the next time info reports it to
+// plugin. Position is a sample from the start, ppq position is counted with
+// tempo of the last change. It's safe to call SetPosition while processor
+// is running, new position is reported from the next buffer.
+func (p *Processor) SetPosition(position int64) {
+	p.m.Lock()
+	p.currentPosition = position
+	p.transport.changed = true
+	p.m.Unlock()
+}
+
+// SetLoop sets region of looped playback in samples. When position reaches
+// the end of region, it's moved to the start, so tempo-synced plugins stay
+// locked to the loop. Plugin is told that cycle is active and receives the
+// region in ppq. Region with end not after start disables the loop.
+func (p *Processor) SetLoop(start, end int64) {
+	p.m.Lock()
+	p.loop = loopRegion{start: start, end: end}
+	p.m.Unlock()
+}
+
+// loopInfo returns loop region in ppq if loop is active.
+func (p *Processor) loopInfo() (active bool, start, end float64) {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	if !p.loop.active() {
+		return false, 0, 0
+	}
+	return true, p.ppq(p.loop.start), p.ppq(p.loop.end)
+}
+
+// setCycle updates loop fields of time info returned by vst2.Plugin.SetTimeInfo.
+func setCycle(timeInfo int64, active bool, start, end float64) {
+	if timeInfo == 0 {
+		return
+	}
+	C.setCycle(C.VstIntPtr(timeInfo), C.int(boolToInt(active)), C.double(start), C.double(end))
+}
+
+// transportState is a state of transport reported to plugin.
+type transportState struct {
+	stopped   bool // transport is playing by default.
+	recording bool
+	changed   bool // state changed since it was reported.
+}
+
+// SetPlaying sets if transport is playing. Plugins use it to start
+// tempo-synced modulation and arpeggiators. Transport is playing by
+// default. It's safe to call SetPlaying while processor is running.
+func (p *Processor) SetPlaying(playing bool) {
+	p.m.Lock()
+	if p.transport.stopped == playing {
+		p.transport.stopped = !playing
+		p.transport.changed = true
+	}
+	p.m.Unlock()
+}
+
+// SetRecording sets if transport is recording. It's safe to call
+// SetRecording while processor is running.
+func (p *Processor) SetRecording(recording bool) {
+	p.m.Lock()
+	if p.transport.recording != recording {
+		p.transport.recording = recording
+		p.transport.changed = true
+	}
+	p.m.Unlock()
+}
+
+// reportTransport returns transport state and marks it as reported.
+func (p *Processor) reportTransport() transportState {
+	p.m.Lock()
+	defer p.m.Unlock()
+	state := p.transport
+	p.transport.changed = false
+	return state
+}
+
+// setState updates transport flags of time info returned by vst2.Plugin.SetTimeInfo.
+func setState(timeInfo int64, state transportState) {
+	if timeInfo == 0 {
+		return
+	}
+	C.setState(C.VstIntPtr(timeInfo), C.int(boolToInt(!state.stopped)), C.int(boolToInt(state.recording)), C.int(boolToInt(state.changed)))
+}
@@ -1,94 +1,817 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
 package vst2
 
 import (
-	"log"
+	"fmt"
 	"math"
+	"sync"
+	"sync/atomic"
 	"time"
 	"unsafe"
 
 	"github.com/dudk/phono"
+	"github.com/dudk/phono/log"
 	"github.com/dudk/vst2"
 )
 
 // Processor represents vst2 sound processor
 type Processor struct {
 	phono.UID
-	plugin *vst2.Plugin
+	plugin *Plugin
+	log    log.Logger
+
+	numChannels phono.NumChannels
+	midi        midiQueue
+	automation  automation
+	bypass      atomic.Value // bypassState
+	flushTail   bool
+	draining    bool
+	tailLeft    int           // samples of tail to process.
+	timeout     time.Duration // maximum duration of plugin's processing, 0 means no limit.
+	crossfade   time.Duration // length of declick ramp, 0 means disabled.
+	declick     declick
+	bypassed    bool // bypass state of the last processed buffer.
+	recycle     bool // put input buffers to pool.
+	measure     bool // record duration of plugin's processing.
+	buffered    int  // number of output messages sent without waiting for receiver.
 
-	bufferSize    phono.BufferSize
-	numChannels   phono.NumChannels
-	sampleRate    phono.SampleRate
-	tempo         float32
-	timeSignature vst2.TimeSignature
+	// m guards fields which are read from callback.
+	m                sync.RWMutex
+	bufferSize       phono.BufferSize
+	sampleRate       phono.SampleRate
+	tempo            float32
+	timeSignature    vst2.TimeSignature
+	currentPosition  int64
+	tempoPosition    int64   // position of the last tempo change.
+	tempoPPQ         float64 // ppq position of the last tempo change.
+	signaturePPQ     float64 // ppq position of the first bar with current time signature.
+	processing       bool    // true while plugin processes buffer.
+	streaming        bool    // plugin is started and receives buffers.
+	offline          bool    // plugin renders faster than realtime.
+	faulted          bool    // plugin didn't return from processing in time.
+	handlers         map[vst2.MasterOpcode]vst2.HostCallbackFunc
+	positionCallback func(int64)
+	automateCallback func(AutomationPoint)
+	progressCallback func(int64, float64)
+	progressTotal    int64 // total length of rendered signal, 0 if unknown.
+	loop             loopRegion
+	transport        transportState
+	timeInfoProvider TimeInfoProvider
+	timingChange     timingChange  // tempo and time signature applied at the next buffer.
+	overruns         int64         // buffers processed longer than their duration.
+	maxProcessTime   time.Duration // the longest processing of buffer.
+}
 
-	currentPosition int64
+// bypassState is a bypass mode of processor.
+type bypassState struct {
+	enabled bool
+	soft    bool // plugin bypasses itself.
 }
 
+// infiniteTailDuration limits flush of plugins with infinite tail.
+const infiniteTailDuration = 10 * time.Second
+
+// Default timing values reported to plugin.
+const defaultTempo = 120
+
+var defaultTimeSignature = vst2.TimeSignature{NotesPerBar: 4, NoteValue: 4}
+
 // NewProcessor creates new vst2 processor.
-func NewProcessor(plugin *vst2.Plugin, bufferSize phono.BufferSize, sampleRate phono.SampleRate, numChannels phono.NumChannels) *Processor {
+func NewProcessor(plugin *Plugin, bufferSize phono.BufferSize, sampleRate phono.SampleRate, numChannels phono.NumChannels) *Processor {
 	return &Processor{
 		UID:             phono.NewUID(),
 		plugin:          plugin,
+		log:             log.GetDebugLogger(),
 		currentPosition: 0,
+		tempo:           defaultTempo,
+		timeSignature:   defaultTimeSignature,
 		bufferSize:      bufferSize,
 		sampleRate:      sampleRate,
 		numChannels:     numChannels,
+		transport:       transportState{changed: true},
 	}
 }
 
 // Process returns processor function with default settings initialized.
+// Plugin is resumed when the first buffer is received, so pipe without
+// input doesn't resume and suspend plugin.
 func (p *Processor) Process(string) (phono.ProcessFunc, error) {
+	// stuck call of faulted plugin holds it.
+	if p.isFaulted() {
+		return nil, ErrFaulted
+	}
 	p.plugin.SetCallback(p.callback())
 	p.plugin.SetBufferSize(int(p.bufferSize))
 	p.plugin.SetSampleRate(int(p.sampleRate))
-	p.plugin.SetSpeakerArrangement(int(p.numChannels))
-	p.plugin.Resume()
-	return func(b phono.Buffer) (phono.Buffer, error) {
-		b = p.plugin.Process(b)
-		p.currentPosition += int64(b.Size())
+	p.setSpeakerArrangement()
+	return p.process, nil
+}
+
+// process sends buffer to plugin and returns processed result.
+func (p *Processor) process(b phono.Buffer) (result phono.Buffer, err error) {
+	// plugin failures must not crash the pipe.
+	defer func() {
+		if r := recover(); r != nil {
+			p.setProcessing(false)
+			err = fmt.Errorf("%w: plugin %v panicked: %v", ErrProcessFailed, p.plugin.Name, r)
+		}
+	}()
+	if p.isFaulted() {
+		return nil, ErrFaulted
+	}
+	p.applyTimingChange()
+	if nc := b.NumChannels(); nc > 0 && nc != p.numChannels {
+		p.setNumChannels(nc)
+	}
+	// buffer size is a maximum size, so plugin is reconfigured only for larger buffers.
+	if size := b.Size(); size > p.bufferSize {
+		p.reconfigure(size, p.sampleRate)
+	}
+	if err := p.validate(b); err != nil {
+		return nil, err
+	}
+	p.start()
+	for _, point := range p.automation.pop(p.Position() + int64(b.Size())) {
+		p.plugin.SetParameter(point.Index, point.Value)
+	}
+	events := p.midi.pop(int(b.Size()))
+	bypass, _ := p.bypass.Load().(bypassState)
+	if bypass.enabled != p.bypassed {
+		p.bypassed = bypass.enabled
+		p.startDeclick()
+	}
+	if bypass.enabled && !bypass.soft {
+		p.declick.apply(b)
+		p.advance(int64(b.Size()))
 		return b, nil
-	}, nil
+	}
+	if len(events) > 0 {
+		p.plugin.ProcessMIDI(events)
+	}
+	sanitized := p.plugin.Sanitized()
+	if result, err = p.processPlugin(b); err != nil {
+		return nil, err
+	}
+	if n := p.plugin.Sanitized() - sanitized; n > 0 {
+		p.log.Info(fmt.Sprintf("Plugin %v returned %d NaN or Inf samples: replaced with zero", p.plugin.Name, n))
+	}
+	if result == nil && b.Size() > 0 {
+		return nil, fmt.Errorf("%w: plugin %v returned no output", ErrProcessFailed, p.plugin.Name)
+	}
+	// plugin's layout is reported when arrangement is set, so only unexpected output is logged.
+	if adjusted, ok := conformOutput(result, b); !ok {
+		if result.NumChannels() != phono.NumChannels(p.plugin.NumOutputs()) || result.Size() != b.Size() {
+			p.log.Info(fmt.Sprintf("Plugin %v returned %d channels of %d samples, expected %d channels of %d samples: output is adjusted",
+				p.plugin.Name, result.NumChannels(), result.Size(), b.NumChannels(), b.Size()))
+		}
+		result = adjusted
+	}
+	p.declick.apply(result)
+	p.advance(int64(result.Size()))
+	// input is released only after it's not used anymore.
+	if p.recycle && !sharesStorage(result, b) {
+		phono.PutBuffer(b)
+	}
+	return result, nil
 }
 
-// Flush suspends plugin.
-func (p *Processor) Flush(string) error {
+// sharesStorage returns true if memory of any channel of a overlaps with
+// memory of any channel of b, e.g. when plugin output is its input.
+func sharesStorage(a, b phono.Buffer) bool {
+	for i := range a {
+		for j := range b {
+			if overlaps(a[i], b[j]) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// overlaps returns true if capacities of two slices overlap.
+func overlaps(a, b []float64) bool {
+	if cap(a) == 0 || cap(b) == 0 {
+		return false
+	}
+	aStart := uintptr(unsafe.Pointer(&a[:1][0]))
+	bStart := uintptr(unsafe.Pointer(&b[:1][0]))
+	aEnd := aStart + uintptr(cap(a))*unsafe.Sizeof(a[0])
+	bEnd := bStart + uintptr(cap(b))*unsafe.Sizeof(b[0])
+	return aStart < bEnd && bStart < aEnd
+}
+
+// SetCrossfade enables declick of output when plugin is reconfigured or
+// bypass is toggled. Plugin is suspended to change buffer size, sample
+// rate or number of channels, so its output jumps. With crossfade, output
+// ramps from the last sample before the change to the new signal over
+// the duration, e.g. 5 milliseconds. Zero duration disables crossfade,
+// it's disabled by default. It must be set before processing is started.
+func (p *Processor) SetCrossfade(d time.Duration) {
+	p.crossfade = d
+}
+
+// startDeclick starts ramp of output if crossfade is enabled.
+func (p *Processor) startDeclick() {
+	if p.crossfade <= 0 {
+		return
+	}
+	_, sampleRate := p.settings()
+	p.declick.start(int(float64(sampleRate) * p.crossfade.Seconds()))
+}
+
+// conformOutput checks that output has the same dimensions as input. If it
+// doesn't, missing channels and samples are filled with silence and extra
+// ones are dropped, so the next pipe components receive expected buffers.
+func conformOutput(out, in phono.Buffer) (phono.Buffer, bool) {
+	size := int(in.Size())
+	ok := len(out) == len(in)
+	for i := 0; ok && i < len(out); i++ {
+		ok = len(out[i]) == size
+	}
+	if ok {
+		return out, true
+	}
+	result := phono.GetBuffer(in.NumChannels(), in.Size())
+	for i := range result {
+		if i < len(out) {
+			copy(result[i], out[i])
+		}
+	}
+	phono.PutBuffer(out)
+	return result, false
+}
+
+// processPlugin sends buffer to plugin. If timeout is set and plugin doesn't
+// return in time, processor is marked as faulted.
+func (p *Processor) processPlugin(b phono.Buffer) (phono.Buffer, error) {
+	if p.measure {
+		defer p.measureProcessing(time.Now(), b.Size())
+	}
+	if p.timeout <= 0 {
+		p.setProcessing(true)
+		result := p.plugin.Process(b)
+		p.setProcessing(false)
+		return result, nil
+	}
+	type processed struct {
+		phono.Buffer
+		err error
+	}
+	done := make(chan processed, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				p.setProcessing(false)
+				done <- processed{err: fmt.Errorf("%w: plugin %v panicked: %v", ErrProcessFailed, p.plugin.Name, r)}
+			}
+		}()
+		p.setProcessing(true)
+		result := p.plugin.Process(b)
+		p.setProcessing(false)
+		done <- processed{Buffer: result}
+	}()
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+	select {
+	case r := <-done:
+		return r.Buffer, r.err
+	case <-timer.C:
+		p.m.Lock()
+		p.faulted = true
+		p.m.Unlock()
+		p.plugin.fault()
+		return nil, fmt.Errorf("%w: plugin %v didn't return in %v", ErrProcessFailed, p.plugin.Name, p.timeout)
+	}
+}
+
+// SetRecycle enables reuse of input buffers. Plugin copies input, so once
+// it's processed, input buffer is put to pool and output buffers of the
+// next calls are taken from it. Enable it only if no other component keeps
+// input buffers, see phono.GetBuffer. It must be set before processing is started.
+func (p *Processor) SetRecycle(recycle bool) {
+	p.recycle = recycle
+}
+
+// SetTimeout limits duration of plugin's processing. If plugin doesn't
+// return in time, pipe receives an error and plugin isn't called anymore,
+// so a stuck plugin doesn't block the pipe. Note that cgo calls can't be
+// interrupted: stuck call keeps running and holds the plugin until it
+// returns. That's why Flush and Plugin.Close of faulted plugin return
+// without calling it and the plugin instance is leaked. Zero timeout
+// disables the limit. It must be set before processing is started.
+func (p *Processor) SetTimeout(timeout time.Duration) {
+	p.timeout = timeout
+}
+
+// SetChannelBuffer sets number of output messages which can be sent before
+// the next component receives them, so fast pumps and slow sinks don't
+// wait for each other on every buffer. Default is 0, so every message is
+// handed off. It must be set before pipe is started.
+func (p *Processor) SetChannelBuffer(n int) {
+	if n < 0 {
+		n = 0
+	}
+	p.buffered = n
+}
+
+// ChannelBuffer implements pipe.Bufferer.
+func (p *Processor) ChannelBuffer() int {
+	return p.buffered
+}
+
+// SetMeasureTiming enables measurement of plugin's processing time. Buffer
+// which takes longer than its real-time duration is counted as overrun, so
+// slow plugins could be found. It must be set before processing is started.
+func (p *Processor) SetMeasureTiming(measure bool) {
+	p.measure = measure
+}
+
+// Overruns returns number of buffers which plugin processed longer than
+// their real-time duration since the start of run.
+func (p *Processor) Overruns() int64 {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	return p.overruns
+}
+
+// MaxProcessTime returns the longest processing of buffer since the start of run.
+func (p *Processor) MaxProcessTime() time.Duration {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	return p.maxProcessTime
+}
+
+// measureProcessing compares time since start with duration of buffer.
+func (p *Processor) measureProcessing(start time.Time, size phono.BufferSize) {
+	elapsed := time.Since(start)
+	p.m.Lock()
+	budget := p.sampleRate.DurationOf(int64(size))
+	if elapsed > p.maxProcessTime {
+		p.maxProcessTime = elapsed
+	}
+	overrun := elapsed > budget
+	if overrun {
+		p.overruns++
+	}
+	p.m.Unlock()
+	if overrun {
+		p.log.Info(fmt.Sprintf("Plugin %v processed buffer of %v in %v: overrun", p.plugin.Name, budget, elapsed))
+	}
+}
+
+// isFaulted returns true if plugin didn't return from processing in time.
+func (p *Processor) isFaulted() bool {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	return p.faulted
+}
+
+// validate checks that buffer can be processed by plugin: all channels
+// must have the same size.
+func (p *Processor) validate(b phono.Buffer) error {
+	if b.NumChannels() == 0 {
+		return nil
+	}
+	size := b.Size()
+	for i := range b {
+		if len(b[i]) != int(size) {
+			return fmt.Errorf("Plugin %v received buffer with channels of different size: channel %d has %d samples, expected %d", p.plugin.Name, i, len(b[i]), size)
+		}
+	}
+	return nil
+}
+
+// SetLogger replaces logger of processor. Default logger discards all
+// messages unless PHONO_DEBUG is set, so audio goroutine doesn't write to
+// stderr. Calls from plugin are logged with debug level, messages about
+// adjusted buffers and overruns with info level. Logger must be set before
+// processing is started.
+func (p *Processor) SetLogger(l log.Logger) {
+	p.log = l
+}
+
+// SetFlushTail enables processing of plugin's tail after input is done. Plugin
+// processes silence until tail is over, so reverb or delay decay isn't cut.
+// Infinite tail is flushed for 10 seconds.
+// It must be set before processing is started.
+func (p *Processor) SetFlushTail(flushTail bool) {
+	p.flushTail = flushTail
+}
+
+// Drain implements pipe.Drainer. If tail flush is enabled, it returns
+// buffers of plugin's tail.
+func (p *Processor) Drain(string) (phono.Buffer, error) {
+	// plugin which didn't receive input has no tail.
+	if !p.flushTail || p.isFaulted() || !p.isStreaming() {
+		return nil, phono.ErrEOP
+	}
+	if !p.draining {
+		p.draining = true
+		p.tailLeft = p.plugin.TailSize()
+		if p.tailLeft == InfiniteTail {
+			_, sampleRate := p.settings()
+			p.tailLeft = int(float64(sampleRate) * infiniteTailDuration.Seconds())
+		}
+	}
+	if p.tailLeft <= 0 {
+		p.draining = false
+		return nil, phono.ErrEOP
+	}
+	size := int(p.bufferSize)
+	if p.tailLeft < size {
+		size = p.tailLeft
+	}
+	p.tailLeft -= size
+	return p.process(phono.GetBuffer(p.numChannels, phono.BufferSize(size)))
+}
+
+// Automate schedules parameter changes. Points are applied before processing
+// of the buffer which contains their position, points in the past are applied
+// immediately. Applied points are removed. It's safe to call Automate while
+// processor is running.
+func (p *Processor) Automate(points ...AutomationPoint) {
+	p.automation.push(points)
+}
+
+// Position returns number of samples processed since the start. It's safe
+// to call Position from any goroutine while processor is running.
+func (p *Processor) Position() int64 {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	return p.currentPosition
+}
+
+// SetPositionCallback sets function which is called with new position after
+// every processed buffer. It's called from pipe goroutine, so it must not
+// block. Nil function disables the callback.
+func (p *Processor) SetPositionCallback(fn func(position int64)) {
+	p.m.Lock()
+	p.positionCallback = fn
+	p.m.Unlock()
+}
+
+// SetAutomateCallback sets function which is called when plugin reports
+// parameter change made in its editor. Point has current position, so it
+// can be recorded and passed to Automate later. It's called from host
+// callback, so it must not block. Nil function disables the callback.
+func (p *Processor) SetAutomateCallback(fn func(AutomationPoint)) {
+	p.m.Lock()
+	p.automateCallback = fn
+	p.m.Unlock()
+}
+
+// automated passes parameter change of plugin to automate callback.
+func (p *Processor) automated(index int, value float32) {
+	p.m.RLock()
+	fn, position := p.automateCallback, p.currentPosition
+	p.m.RUnlock()
+	if fn != nil {
+		fn(AutomationPoint{Position: position, Index: index, Value: value})
+	}
+}
+
+// SetProgressCallback sets function which is called after every processed
+// buffer with position and fraction of total length which is processed.
+// Total is a number of samples provided by pump, e.g. wav.Pump.WavSamples.
+// If length is unknown, total must be zero and fraction is -1, so only
+// position is reported. Fraction doesn't exceed 1, even when tail is
+// flushed. It's called from pipe goroutine, so it must not block. Nil
+// function disables the callback.
+func (p *Processor) SetProgressCallback(total int64, fn func(position int64, fraction float64)) {
+	p.m.Lock()
+	p.progressTotal = total
+	p.progressCallback = fn
+	p.m.Unlock()
+}
+
+// advance moves current position forward.
+func (p *Processor) advance(samples int64) {
+	p.m.Lock()
+	next := p.currentPosition + samples
+	p.currentPosition = p.loop.wrap(next)
+	// jump to the start of loop is a transport change.
+	if p.currentPosition != next {
+		p.transport.changed = true
+	}
+	position, fn := p.currentPosition, p.positionCallback
+	progress, total := p.progressCallback, p.progressTotal
+	p.m.Unlock()
+	if fn != nil {
+		fn(position)
+	}
+	if progress != nil {
+		progress(position, fraction(position, total))
+	}
+}
+
+// fraction returns processed fraction of total length or -1 if total is unknown.
+func fraction(position, total int64) float64 {
+	if total <= 0 {
+		return -1
+	}
+	return math.Min(1, float64(position)/float64(total))
+}
+
+// setProcessing marks if plugin is processing buffer.
+func (p *Processor) setProcessing(processing bool) {
+	p.m.Lock()
+	p.processing = processing
+	p.m.Unlock()
+}
+
+// SetOffline enables offline processing mode. Plugin is told that buffers
+// are rendered faster than realtime, so it can use lookahead and
+// oversampling. It must be set before processing is started, because
+// plugin reads the mode when it's resumed.
+func (p *Processor) SetOffline(offline bool) {
+	p.m.Lock()
+	p.offline = offline
+	p.m.Unlock()
+}
+
+// setStreaming marks if plugin is started and receives buffers.
+func (p *Processor) setStreaming(streaming bool) {
+	p.m.Lock()
+	p.streaming = streaming
+	p.m.Unlock()
+}
+
+// isStreaming checks if plugin is started.
+func (p *Processor) isStreaming() bool {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	return p.streaming
+}
+
+// processLevel returns offline level in offline mode and realtime level
+// if called while plugin processes buffer. If editor is open, plugin
+// calls host from GUI thread, so realtime level is reported while audio
+// is streaming and user level when editor is only idle.
+func (p *Processor) processLevel() int {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	if p.offline {
+		return processLevelOffline
+	}
+	if p.processing {
+		return processLevelRealtime
+	}
+	if p.streaming && p.plugin.EditorOpen() {
+		return processLevelRealtime
+	}
+	return processLevelUser
+}
+
+// ppq returns position in quarter notes. Position is counted
+// from the last tempo change, so the change doesn't cause a jump.
+// Caller must hold the lock.
+func (p *Processor) ppq(samplePos int64) float64 {
+	samplesPerBeat := (60.0 / float64(p.tempo)) * float64(p.sampleRate)
+	return p.tempoPPQ + float64(samplePos-p.tempoPosition)/samplesPerBeat
+}
+
+// TempoParam sets tempo reported to plugin. It's applied at the buffer
+// boundary the same way as SetTempo.
+func (p *Processor) TempoParam(tempo float32) phono.Param {
+	return phono.Param{
+		ID: p.ID(),
+		Apply: func() {
+			p.SetTempo(float64(tempo))
+			p.applyTimingChange()
+		},
+	}
+}
+
+// setTempo changes tempo at current position. Caller must hold the lock.
+func (p *Processor) setTempo(tempo float32) {
+	p.tempoPPQ = p.ppq(p.currentPosition)
+	p.tempoPosition = p.currentPosition
+	p.tempo = tempo
+}
+
+// TimeSignatureParam sets time signature reported to plugin. It's applied
+// at the buffer boundary the same way as SetTimeSignature.
+func (p *Processor) TimeSignatureParam(timeSignature vst2.TimeSignature) phono.Param {
+	return phono.Param{
+		ID: p.ID(),
+		Apply: func() {
+			p.SetTimeSignature(timeSignature.NotesPerBar, timeSignature.NoteValue)
+			p.applyTimingChange()
+		},
+	}
+}
+
+// SampleRateParam changes sample rate of plugin. It's applied at the buffer boundary.
+func (p *Processor) SampleRateParam(sampleRate phono.SampleRate) phono.Param {
+	return phono.Param{
+		ID: p.ID(),
+		Apply: func() {
+			if sampleRate != p.sampleRate {
+				p.reconfigure(p.bufferSize, sampleRate)
+			}
+		},
+	}
+}
+
+// reconfigure re-sets buffer size and sample rate of plugin. Plugin must be suspended for that.
+func (p *Processor) reconfigure(bufferSize phono.BufferSize, sampleRate phono.SampleRate) {
+	streaming := p.isStreaming()
+	p.stop()
+	p.m.Lock()
+	// ppq position depends on sample rate, so it's counted from this point.
+	p.tempoPPQ = p.ppq(p.currentPosition)
+	p.tempoPosition = p.currentPosition
+	p.bufferSize = bufferSize
+	p.sampleRate = sampleRate
+	p.m.Unlock()
+	p.plugin.SetBufferSize(int(bufferSize))
+	p.plugin.SetSampleRate(int(sampleRate))
+	if streaming {
+		p.start()
+		p.startDeclick()
+	}
+}
+
+// settings returns buffer size and sample rate of plugin.
+func (p *Processor) settings() (phono.BufferSize, phono.SampleRate) {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	return p.bufferSize, p.sampleRate
+}
+
+// setNumChannels re-sets speaker arrangement of plugin. Plugin must be suspended for that.
+func (p *Processor) setNumChannels(nc phono.NumChannels) {
+	p.numChannels = nc
+	streaming := p.isStreaming()
+	p.stop()
+	p.setSpeakerArrangement()
+	if streaming {
+		p.start()
+		p.startDeclick()
+	}
+}
+
+// setSpeakerArrangement sends speaker arrangement to plugin. Number of
+// channels is limited by plugin's inputs and outputs, so plugin isn't
+// asked to process channels it doesn't have. If arrangement is rejected,
+// plugin keeps processing with its default layout.
+func (p *Processor) setSpeakerArrangement() {
+	numInputs, numOutputs := p.plugin.NumInputs(), p.plugin.NumOutputs()
+	if int(p.numChannels) > numInputs || int(p.numChannels) != numOutputs {
+		p.log.Info(fmt.Sprintf("Plugin %v has %d inputs and %d outputs, but buffers have %d channels: missing channels are silent and extra ones are dropped",
+			p.plugin.Name, numInputs, numOutputs, p.numChannels))
+	}
+	if n := int(p.numChannels); n < numInputs {
+		numInputs = n
+	}
+	if n := int(p.numChannels); n < numOutputs {
+		numOutputs = n
+	}
+	if !p.plugin.SetSpeakerArrangement(numInputs, numOutputs) {
+		p.log.Debug("Plugin ", p.plugin.Name, " doesn't support arrangement of ", numInputs, " inputs and ", numOutputs, " outputs")
+	}
+}
+
+// start resumes plugin and starts processing.
+// Faulted plugin is busy, so it's not called.
+func (p *Processor) start() {
+	if p.isFaulted() || p.isStreaming() {
+		return
+	}
+	p.plugin.Resume()
+	p.plugin.StartProcess()
+	p.setStreaming(true)
+}
+
+// stop stops processing and suspends plugin.
+// Faulted plugin is busy, so it's not called.
+func (p *Processor) stop() {
+	if p.isFaulted() || !p.isStreaming() {
+		return
+	}
+	p.setStreaming(false)
+	p.plugin.StopProcess()
 	p.plugin.Suspend()
+}
+
+// SetBypass enables or disables bypass mode. If plugin supports soft bypass,
+// it keeps processing buffers, so tail and latency are handled by plugin.
+// Otherwise buffers are passed through unchanged. It's safe to call
+// SetBypass while processor is running.
+func (p *Processor) SetBypass(bypass bool) {
+	soft := p.plugin.SetBypass(bypass)
+	p.bypass.Store(bypassState{enabled: bypass, soft: soft})
+}
+
+// InitialDelay returns latency of plugin in samples, so parallel paths could be aligned
+// with processor output. Plugins may change it on resume, so it should be read after
+// processing is started.
+func (p *Processor) InitialDelay() int {
+	return p.plugin.InitialDelay()
+}
+
+// SendMIDI queues MIDI events which are sent to plugin before the next buffer is processed.
+// Events with delta frames beyond the buffer size are carried over to the following buffers.
+func (p *Processor) SendMIDI(events []MIDIEvent) {
+	p.midi.push(events)
+}
+
+// Reset implements pipe.Resetter. It moves processor to the start position,
+// drops queued MIDI events and suspends plugin, so every run starts from the
+// same state. Plugin is resumed again with the first buffer.
+func (p *Processor) Reset(string) error {
+	p.m.Lock()
+	p.currentPosition = 0
+	p.tempoPosition = 0
+	p.tempoPPQ = 0
+	p.signaturePPQ = 0
+	p.transport.changed = true
+	p.overruns = 0
+	p.maxProcessTime = 0
+	p.m.Unlock()
+	p.midi.clear()
+	p.declick.reset()
+	p.draining = false
+	p.stop()
 	return nil
 }
 
+// Flush stops processing and suspends plugin.
+func (p *Processor) Flush(string) error {
+	p.stop()
+	return nil
+}
+
+// HandleOpcode registers handler for host callback opcode. Handler takes
+// precedence over the built-in answers, opcodes without handlers are
+// answered as usual. Nil handler removes the registered one.
+func (p *Processor) HandleOpcode(opcode vst2.MasterOpcode, handler vst2.HostCallbackFunc) {
+	p.m.Lock()
+	defer p.m.Unlock()
+	if handler == nil {
+		delete(p.handlers, opcode)
+		return
+	}
+	if p.handlers == nil {
+		p.handlers = make(map[vst2.MasterOpcode]vst2.HostCallbackFunc)
+	}
+	p.handlers[opcode] = handler
+}
+
+// handler returns registered handler for opcode.
+func (p *Processor) handler(opcode vst2.MasterOpcode) vst2.HostCallbackFunc {
+	p.m.RLock()
+	defer p.m.RUnlock()
+	return p.handlers[opcode]
+}
+
 // wraped callback with session.
 func (p *Processor) callback() vst2.HostCallbackFunc {
 	return func(plugin *vst2.Plugin, opcode vst2.MasterOpcode, index int64, value int64, ptr unsafe.Pointer, opt float64) int {
+		if handler := p.handler(opcode); handler != nil {
+			return handler(plugin, opcode, index, value, ptr, opt)
+		}
+		if result, ok := hostInfo(opcode, ptr); ok {
+			return result
+		}
 		switch opcode {
 		case vst2.AudioMasterIdle:
-			log.Printf("AudioMasterIdle")
-			plugin.Dispatch(vst2.EffEditIdle, 0, 0, nil, 0)
+			p.log.Debug("AudioMasterIdle")
+			// idle calls are only needed to redraw open editor.
+			if p.plugin.EditorOpen() {
+				plugin.Dispatch(vst2.EffEditIdle, 0, 0, nil, 0)
+			}
 
+		case vst2.AudioMasterAutomate:
+			// value of parameter is passed in opt.
+			p.automated(int(index), float32(opt))
+			return 1
 		case vst2.AudioMasterGetCurrentProcessLevel:
-			//TODO: return C.kVstProcessLevel
+			return p.processLevel()
 		case vst2.AudioMasterGetSampleRate:
-			return int(p.sampleRate)
+			_, sampleRate := p.settings()
+			return int(sampleRate)
 		case vst2.AudioMasterGetBlockSize:
-			return int(p.bufferSize)
+			bufferSize, _ := p.settings()
+			return int(bufferSize)
 		case vst2.AudioMasterGetTime:
 			nanoseconds := time.Now().UnixNano()
-			notesPerMeasure := p.timeSignature.NotesPerBar
-			//TODO: make this dynamic (handle time signature changes)
-			// samples position
-			samplePos := p.currentPosition
-			// todo tempo
-			tempo := p.tempo
-
-			samplesPerBeat := (60.0 / float64(tempo)) * float64(p.sampleRate)
-			// todo: ppqPos
-			ppqPos := float64(samplePos)/samplesPerBeat + 1.0
-			// todo: barPos
-			barPos := math.Floor(ppqPos / float64(notesPerMeasure))
-
-			return int(plugin.SetTimeInfo(int(p.sampleRate), samplePos, float32(tempo), p.timeSignature, nanoseconds, ppqPos, barPos))
+			_, sampleRate := p.settings()
+			samplePos, tempo, timeSignature, ppqPos, barPos := p.timing()
+			timeInfo := plugin.SetTimeInfo(int(sampleRate), samplePos, tempo, timeSignature, nanoseconds, ppqPos, barPos)
+			active, start, end := p.loopInfo()
+			setCycle(timeInfo, active, start, end)
+			setState(timeInfo, p.reportTransport())
+			return int(timeInfo)
+		case vst2.AudioMasterSizeWindow:
+			// editor's window belongs to caller, so request is only accepted.
+			// Register handler for this opcode to resize the window.
+			p.log.Debug("Plugin requested editor size ", index, "x", value)
+			return 1
 		default:
-			// log.Printf("Plugin requested value of opcode %v\n", opcode)
-			break
+			p.log.Debug("Plugin requested value of opcode ", opcode)
 		}
 		return 0
 	}
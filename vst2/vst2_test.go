@@ -0,0 +1,714 @@
+//go:build cgo && !novst2
+// +build cgo,!novst2
+
+package vst2_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/mock"
+	"github.com/dudk/phono/pipe"
+	"github.com/dudk/phono/test"
+	"github.com/dudk/phono/vst2"
+	"github.com/dudk/phono/wav"
+	vst2sdk "github.com/dudk/vst2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPluginParameters(t *testing.T) {
+	lib, err := vst2sdk.Open(test.Vst)
+	assert.Nil(t, err)
+	defer lib.Close()
+	p, err := lib.Open()
+	assert.Nil(t, err)
+	defer p.Close()
+	plugin := vst2.NewPlugin(p)
+
+	assert.True(t, plugin.NumParameters() > 0)
+	assert.True(t, plugin.InitialDelay() >= 0)
+	info := plugin.Info()
+	assert.NotEmpty(t, info.Name)
+	assert.Equal(t, test.Vst, info.Path)
+	assert.True(t, info.NumOutputs > 0)
+	assert.Equal(t, info.NumInputs, plugin.NumInputs())
+	assert.Equal(t, info.NumOutputs, plugin.NumOutputs())
+	assert.False(t, plugin.IsSynth())
+	assert.Equal(t, vst2.CategoryEffect, plugin.Category())
+	assert.Equal(t, "Effect", plugin.Category().String())
+	assert.Equal(t, info.HasEditor, plugin.HasEditor())
+	assert.Equal(t, p.CanProcessFloat32(), info.CanReplacing)
+	assert.Equal(t, p.CanProcessFloat64(), info.CanDoubleReplacing)
+	assert.NotZero(t, info.Flags)
+	assert.False(t, plugin.CanDo("not-existing-feature"))
+
+	// parameters can be found by name.
+	name := plugin.ParameterName(0)
+	index, ok := plugin.ParameterByName(name)
+	assert.True(t, ok)
+	assert.Equal(t, 0, index)
+	assert.True(t, plugin.SetParameterByName(" "+name+" ", 0.25))
+	assert.Equal(t, float32(0.25), plugin.GetParameter(0))
+	_, ok = plugin.ParameterByName("not-existing-parameter")
+	assert.False(t, ok)
+	assert.False(t, plugin.SetParameterByName("not-existing-parameter", 0.25))
+	assert.NotEmpty(t, plugin.ParameterName(0))
+	plugin.SetParameter(0, 0.3)
+	assert.InDelta(t, 0.3, plugin.GetParameter(0), 0.001)
+}
+
+func TestPluginProcess(t *testing.T) {
+	lib, err := vst2sdk.Open(test.Vst)
+	assert.Nil(t, err)
+	defer lib.Close()
+	p, err := lib.Open()
+	assert.Nil(t, err)
+	plugin := vst2.NewPlugin(p)
+	defer plugin.Close()
+	plugin.Resume()
+
+	// buffers of different size must be processed with reallocated memory.
+	for _, size := range []int{512, 512, 256, 1024} {
+		in := [][]float64{make([]float64, size), make([]float64, size)}
+		out := plugin.Process(in)
+		assert.Equal(t, len(in), len(out))
+		for i := range out {
+			assert.Equal(t, size, len(out[i]))
+		}
+	}
+
+	// output has a channel for every plugin's output.
+	out := plugin.Process([][]float64{make([]float64, 512)})
+	assert.Equal(t, plugin.NumOutputs(), len(out))
+
+	// float32 buffers are processed without float64 round trip.
+	out32 := plugin.ProcessFloat32([][]float32{make([]float32, 256), make([]float32, 100)})
+	assert.Equal(t, 2, len(out32))
+	for i := range out32 {
+		assert.Equal(t, 256, len(out32[i]))
+	}
+
+	// short buffer is padded to block size and result is truncated back.
+	plugin.SetBufferSize(512)
+	out = plugin.Process([][]float64{make([]float64, 100), make([]float64, 100)})
+	for i := range out {
+		assert.Equal(t, 100, len(out[i]))
+		assert.Equal(t, 100, cap(out[i]))
+	}
+	out32 = plugin.ProcessFloat32([][]float32{make([]float32, 100), make([]float32, 100)})
+	for i := range out32 {
+		assert.Equal(t, 100, len(out32[i]))
+	}
+
+	// double precision is used if plugin supports it.
+	if p.CanProcessFloat64() {
+		plugin.SetPrecision(vst2.Float64)
+		assert.False(t, plugin.PrefersFloat32())
+		out := plugin.Process([][]float64{make([]float64, 512), make([]float64, 512)})
+		assert.Equal(t, 2, len(out))
+		plugin.SetPrecision(vst2.Auto)
+		assert.Equal(t, p.CanProcessFloat32(), plugin.PrefersFloat32())
+	}
+}
+
+func TestWithCallback(t *testing.T) {
+	lib, err := vst2sdk.Open(test.Vst)
+	assert.Nil(t, err)
+	defer lib.Close()
+	p, err := lib.Open()
+	assert.Nil(t, err)
+	plugin := vst2.NewPlugin(p)
+	defer plugin.Close()
+	plugin.Resume()
+
+	var calls int64
+	callback := func(*vst2sdk.Plugin, vst2sdk.MasterOpcode, int64, int64, unsafe.Pointer, float64) int {
+		atomic.AddInt64(&calls, 1)
+		return 0
+	}
+	previous := plugin.WithCallback(callback)
+	assert.NotNil(t, previous)
+
+	// callbacks are swapped while plugin is processing.
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			plugin.SetCallback(callback)
+			plugin.SetCallback(nil)
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		plugin.Process([][]float64{make([]float64, 64), make([]float64, 64)})
+	}
+	<-done
+
+	plugin.SetCallback(callback)
+	plugin.Process([][]float64{make([]float64, 64), make([]float64, 64)})
+	assert.True(t, atomic.LoadInt64(&calls) > 0)
+	plugin.WithCallback(previous)
+}
+
+func TestPrograms(t *testing.T) {
+	lib, err := vst2sdk.Open(test.Vst)
+	assert.Nil(t, err)
+	defer lib.Close()
+	p, err := lib.Open()
+	assert.Nil(t, err)
+	plugin := vst2.NewPlugin(p)
+	defer plugin.Close()
+
+	if plugin.NumPrograms() < 2 {
+		t.Skip("plugin has no programs to select")
+	}
+	plugin.SetProgram(1)
+	assert.Equal(t, 1, plugin.CurrentProgram())
+	assert.NotEmpty(t, plugin.ProgramName())
+}
+
+func TestPreset(t *testing.T) {
+	lib, err := vst2sdk.Open(test.Vst)
+	assert.Nil(t, err)
+	defer lib.Close()
+	p, err := lib.Open()
+	assert.Nil(t, err)
+	defer p.Close()
+	plugin := vst2.NewPlugin(p)
+
+	plugin.SetParameter(0, 0.3)
+	err = plugin.SavePreset(test.Out.Fxp)
+	assert.Nil(t, err)
+	plugin.SetParameter(0, 0.7)
+	err = plugin.LoadPreset(test.Out.Fxp)
+	assert.Nil(t, err)
+	assert.Equal(t, float32(0.3), plugin.GetParameter(0))
+
+	err = plugin.SavePreset(test.Out.Fxb)
+	assert.Nil(t, err)
+	err = plugin.LoadPreset(test.Out.Fxb)
+	assert.Nil(t, err)
+
+	err = plugin.LoadPreset(test.Data.Wav1)
+	assert.Equal(t, vst2.ErrInvalidPreset, err)
+}
+
+func TestInvalidPreset(t *testing.T) {
+	plugin, err := vst2.Open(test.Vst)
+	assert.Nil(t, err)
+	defer plugin.Close()
+	dir, err := ioutil.TempDir("", "phono")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+
+	// preset returns file with header for plugin and provided body.
+	preset := func(fxMagic string, count int32, body ...interface{}) string {
+		var b bytes.Buffer
+		b.WriteString("CcnK")
+		binary.Write(&b, binary.BigEndian, int32(0))
+		b.WriteString(fxMagic)
+		binary.Write(&b, binary.BigEndian, []int32{1, plugin.Info().UniqueID, 1, count})
+		for _, v := range body {
+			binary.Write(&b, binary.BigEndian, v)
+		}
+		path := filepath.Join(dir, "preset.fxp")
+		assert.Nil(t, ioutil.WriteFile(path, b.Bytes(), 0644))
+		return path
+	}
+	name := make([]byte, 28)
+	future := make([]byte, 128)
+	tests := map[string]string{
+		"negative parameters":  preset("FxCk", -1, name),
+		"oversized parameters": preset("FxCk", 1<<30, name),
+		"truncated parameters": preset("FxCk", int32(plugin.NumParameters()), name, float32(0.5)),
+		"negative chunk":       preset("FPCh", 0, name, int32(-1)),
+		"oversized chunk":      preset("FPCh", 0, name, int32(1<<30), []byte{1, 2}),
+		"negative programs":    preset("FxBk", -1, future),
+		"oversized programs":   preset("FxBk", 1<<30, future),
+		"truncated bank":       preset("FxBk", 1, future),
+	}
+	for name, path := range tests {
+		err := plugin.LoadPreset(path)
+		assert.Equal(t, vst2.ErrInvalidPreset, err, name)
+	}
+
+	// truncated file saved by plugin.
+	path := filepath.Join(dir, "saved.fxp")
+	assert.Nil(t, plugin.SavePreset(path))
+	data, err := ioutil.ReadFile(path)
+	assert.Nil(t, err)
+	for _, size := range []int{8, 30, len(data) - 1} {
+		assert.Nil(t, ioutil.WriteFile(path, data[:size], 0644))
+		assert.Equal(t, vst2.ErrInvalidPreset, plugin.LoadPreset(path), size)
+	}
+}
+
+func TestScan(t *testing.T) {
+	infos, err := vst2.Scan([]string{filepath.Dir(test.Vst), "~/not-existing-path"})
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(infos))
+	for _, info := range infos {
+		assert.NotEmpty(t, info.Name)
+		assert.NotEqual(t, 0, info.UniqueID)
+	}
+}
+
+func TestScanBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "phono")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	// files inside of bundle aren't scanned.
+	macos := filepath.Join(dir, "Plugin.vst", "Contents", "MacOS")
+	assert.Nil(t, os.MkdirAll(macos, 0755))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(macos, "Plugin"+vst2.FileExtension()), nil, 0644))
+	infos, err := vst2.Scan([]string{dir})
+	if runtime.GOOS == "darwin" {
+		assert.NotNil(t, err)
+		assert.Equal(t, 1, len(err.(vst2.ScanErrors)))
+	} else {
+		assert.Nil(t, err)
+	}
+	assert.Equal(t, 0, len(infos))
+}
+
+func TestOpen(t *testing.T) {
+	plugin, err := vst2.Open(test.Vst)
+	assert.Nil(t, err)
+	assert.NotEmpty(t, plugin.Info().Name)
+	assert.Nil(t, plugin.Close())
+
+	// relative path is resolved against working directory.
+	wd, err := os.Getwd()
+	assert.Nil(t, err)
+	abs, err := filepath.Abs(test.Vst)
+	assert.Nil(t, err)
+	rel, err := filepath.Rel(wd, abs)
+	assert.Nil(t, err)
+	plugin, err = vst2.Open(rel)
+	assert.Nil(t, err)
+	assert.Equal(t, abs, plugin.Info().Path)
+	assert.Nil(t, plugin.Close())
+
+	path := filepath.Join(filepath.Dir(test.Vst), "not-existing.vst")
+	_, err = vst2.Open(path)
+	assert.True(t, errors.Is(err, vst2.ErrNotFound))
+	assert.Contains(t, err.Error(), path)
+
+	dir, err := ioutil.TempDir("", "phono")
+	assert.Nil(t, err)
+	defer os.RemoveAll(dir)
+	path = filepath.Join(dir, "invalid"+vst2.FileExtension())
+	assert.Nil(t, ioutil.WriteFile(path, []byte("not a plugin"), 0644))
+	_, err = vst2.Open(path)
+	assert.True(t, errors.Is(err, vst2.ErrInvalidPlugin))
+	assert.Contains(t, err.Error(), path)
+}
+
+func TestErrors(t *testing.T) {
+	assert.True(t, errors.Is(vst2.ErrNoChunks, vst2.ErrUnsupportedOpcode))
+	assert.True(t, errors.Is(vst2.ErrNoEditor, vst2.ErrUnsupportedOpcode))
+	assert.True(t, errors.Is(vst2.ErrFaulted, vst2.ErrProcessFailed))
+}
+
+func TestClose(t *testing.T) {
+	lib, err := vst2.OpenLibrary(test.Vst)
+	assert.Nil(t, err)
+	first, err := lib.Open()
+	assert.Nil(t, err)
+	second, err := lib.Open()
+	assert.Nil(t, err)
+
+	// plugin can't be closed while processor is running.
+	processor := vst2.NewProcessor(first, 512, 44100, 2)
+	fn, err := processor.Process("")
+	assert.Nil(t, err)
+	// plugin is resumed with the first buffer.
+	assert.Nil(t, first.Close())
+	first, err = lib.Open()
+	assert.Nil(t, err)
+	processor = vst2.NewProcessor(first, 512, 44100, 2)
+	fn, err = processor.Process("")
+	assert.Nil(t, err)
+	_, err = fn(phono.EmptyBuffer(2, 512))
+	assert.Nil(t, err)
+	assert.Equal(t, vst2.ErrProcessing, first.Close())
+	assert.Nil(t, processor.Flush(""))
+	assert.Nil(t, first.Close())
+	assert.Nil(t, first.Close())
+
+	assert.Nil(t, second.Close())
+	assert.Nil(t, lib.Close())
+	assert.Nil(t, lib.Close())
+	_, err = lib.Open()
+	assert.Equal(t, vst2.ErrClosed, err)
+}
+
+func TestOpenShell(t *testing.T) {
+	lib, err := vst2.OpenLibrary(test.Vst)
+	assert.Nil(t, err)
+	defer lib.Close()
+	// test plugin isn't a shell, so it has no sub-plugins.
+	_, err = lib.ShellPlugins()
+	assert.True(t, errors.Is(err, vst2.ErrUnsupportedOpcode))
+
+	plugin, err := lib.OpenShell(0)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, plugin.ShellID())
+	// callbacks of plugin opened with OpenShell reach processor.
+	processor := vst2.NewProcessor(plugin, 512, 44100, 2)
+	fn, err := processor.Process("")
+	assert.Nil(t, err)
+	_, err = fn(phono.EmptyBuffer(2, 512))
+	assert.Nil(t, err)
+	assert.Nil(t, processor.Flush(""))
+	assert.Nil(t, plugin.Close())
+
+	assert.Nil(t, lib.Close())
+	_, err = lib.OpenShell(0)
+	assert.Equal(t, vst2.ErrClosed, err)
+
+	// library is closed with plugin.
+	plugin, err = vst2.OpenShell(test.Vst, 0)
+	assert.Nil(t, err)
+	assert.Nil(t, plugin.Close())
+}
+
+func TestDefaultScanPaths(t *testing.T) {
+	for _, path := range vst2.DefaultScanPaths() {
+		assert.True(t, filepath.IsAbs(path))
+		assert.Equal(t, strings.TrimSpace(path), path)
+	}
+}
+
+func TestState(t *testing.T) {
+	lib, err := vst2sdk.Open(test.Vst)
+	assert.Nil(t, err)
+	defer lib.Close()
+	p, err := lib.Open()
+	assert.Nil(t, err)
+	plugin := vst2.NewPlugin(p)
+	defer plugin.Close()
+
+	state, err := plugin.GetState()
+	if err == vst2.ErrNoChunks {
+		assert.Equal(t, vst2.ErrNoChunks, plugin.SetState(state))
+		return
+	}
+	assert.Nil(t, err)
+	assert.Nil(t, plugin.SetState(state))
+}
+
+func TestEditor(t *testing.T) {
+	lib, err := vst2sdk.Open(test.Vst)
+	assert.Nil(t, err)
+	defer lib.Close()
+	p, err := lib.Open()
+	assert.Nil(t, err)
+	plugin := vst2.NewPlugin(p)
+	defer plugin.Close()
+
+	err = plugin.OpenEditor(nil)
+	assert.NotNil(t, err)
+	width, height := plugin.EditorRect()
+	assert.True(t, width >= 0 && height >= 0)
+}
+
+func TestProcessorTail(t *testing.T) {
+	lib, err := vst2sdk.Open(test.Vst)
+	assert.Nil(t, err)
+	defer lib.Close()
+	p, err := lib.Open()
+	assert.Nil(t, err)
+	plugin := vst2.NewPlugin(p)
+	defer plugin.Close()
+
+	pump := &mock.Pump{
+		UID:         phono.NewUID(),
+		Limit:       5,
+		BufferSize:  512,
+		NumChannels: 2,
+	}
+	processor := vst2.NewProcessor(plugin, 512, 44100, 2)
+	processor.SetFlushTail(true)
+	sink := &mock.Sink{UID: phono.NewUID()}
+	playback, err := pipe.New(
+		44100,
+		pipe.WithPump(pump),
+		pipe.WithProcessors(processor),
+		pipe.WithSinks(sink),
+	)
+	assert.Nil(t, err)
+	err = pipe.Wait(playback.Run())
+	assert.Nil(t, err)
+	_, samples := sink.Count()
+	assert.Equal(t, int64(5*512+plugin.TailSize()), samples)
+	_ = pipe.Wait(playback.Close())
+}
+
+func TestProcessorValidation(t *testing.T) {
+	lib, err := vst2sdk.Open(test.Vst)
+	assert.Nil(t, err)
+	defer lib.Close()
+	p, err := lib.Open()
+	assert.Nil(t, err)
+	plugin := vst2.NewPlugin(p)
+	defer plugin.Close()
+
+	processor := vst2.NewProcessor(plugin, 512, 44100, 2)
+	fn, err := processor.Process("")
+	assert.Nil(t, err)
+	_, err = fn(phono.Buffer{make([]float64, 512), make([]float64, 256)})
+	assert.NotNil(t, err)
+	_, err = fn(phono.Buffer{make([]float64, 512), make([]float64, 512)})
+	assert.Nil(t, err)
+	// larger buffer reconfigures plugin.
+	result, err := fn(phono.Buffer{make([]float64, 1024), make([]float64, 1024)})
+	assert.Nil(t, err)
+	assert.Equal(t, phono.BufferSize(1024), result.Size())
+	// buffer with fewer channels than plugin's inputs is processed.
+	result, err = fn(phono.Buffer{make([]float64, 512)})
+	assert.Nil(t, err)
+	assert.Equal(t, phono.NumChannels(1), result.NumChannels())
+	assert.Nil(t, processor.Flush(""))
+}
+
+func TestProcessorRecycle(t *testing.T) {
+	plugin, err := vst2.Open(test.Vst)
+	assert.Nil(t, err)
+	defer plugin.Close()
+
+	var outputs []phono.Buffer
+	for _, recycle := range []bool{false, true} {
+		pump := &mock.Pump{
+			UID:         phono.NewUID(),
+			Limit:       5,
+			BufferSize:  512,
+			NumChannels: 2,
+			Value:       0.5,
+		}
+		processor := vst2.NewProcessor(plugin, 512, 44100, 2)
+		processor.SetRecycle(recycle)
+		sink := &mock.Sink{UID: phono.NewUID()}
+		p, err := pipe.New(
+			44100,
+			pipe.WithPump(pump),
+			pipe.WithProcessors(processor),
+			pipe.WithSinks(sink),
+		)
+		assert.Nil(t, err)
+		assert.Nil(t, pipe.Wait(p.Run()))
+		assert.Nil(t, pipe.Wait(p.Close()))
+		outputs = append(outputs, sink.Buffer)
+	}
+	// input buffers are consumed by plugin, so output doesn't change.
+	assert.Equal(t, outputs[0], outputs[1])
+}
+
+func TestProcessorChannelBuffer(t *testing.T) {
+	plugin, err := vst2.Open(test.Vst)
+	assert.Nil(t, err)
+	defer plugin.Close()
+
+	pump := &mock.Pump{
+		UID:         phono.NewUID(),
+		Limit:       10,
+		BufferSize:  512,
+		NumChannels: 2,
+		Value:       0.5,
+	}
+	processor := vst2.NewProcessor(plugin, 512, 44100, 2)
+	processor.SetChannelBuffer(4)
+	var bufferer pipe.Bufferer = processor
+	assert.Equal(t, 4, bufferer.ChannelBuffer())
+	sink := &mock.Sink{UID: phono.NewUID()}
+	p, err := pipe.New(
+		44100,
+		pipe.WithPump(pump),
+		pipe.WithProcessors(processor),
+		pipe.WithSinks(sink),
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, pipe.Wait(p.Run()))
+	assert.Nil(t, pipe.Wait(p.Close()))
+	messages, samples := sink.Count()
+	assert.Equal(t, int64(10), messages)
+	assert.Equal(t, int64(10*512), samples)
+}
+
+func TestProcessorReset(t *testing.T) {
+	lib, err := vst2sdk.Open(test.Vst)
+	assert.Nil(t, err)
+	defer lib.Close()
+	p, err := lib.Open()
+	assert.Nil(t, err)
+	plugin := vst2.NewPlugin(p)
+	defer plugin.Close()
+
+	pump := &mock.Pump{
+		UID:         phono.NewUID(),
+		Limit:       5,
+		BufferSize:  512,
+		NumChannels: 2,
+		Value:       0.5,
+	}
+	processor := vst2.NewProcessor(plugin, 512, 44100, 2)
+	sink := &mock.Sink{UID: phono.NewUID()}
+	playback, err := pipe.New(
+		44100,
+		pipe.WithPump(pump),
+		pipe.WithProcessors(processor),
+		pipe.WithSinks(sink),
+	)
+	assert.Nil(t, err)
+	err = pipe.Wait(playback.Run())
+	assert.Nil(t, err)
+	first := sink.Buffer
+
+	// second run must produce the same output.
+	err = pipe.Wait(playback.Run())
+	assert.Nil(t, err)
+	assert.Equal(t, first, sink.Buffer)
+	_ = pipe.Wait(playback.Close())
+}
+
+func TestChain(t *testing.T) {
+	lib, err := vst2sdk.Open(test.Vst)
+	assert.Nil(t, err)
+	defer lib.Close()
+	plugins := make([]*vst2.Plugin, 2)
+	for i := range plugins {
+		p, err := lib.Open()
+		assert.Nil(t, err)
+		plugins[i] = vst2.NewPlugin(p)
+		defer plugins[i].Close()
+	}
+
+	pump := &mock.Pump{
+		UID:         phono.NewUID(),
+		Limit:       5,
+		BufferSize:  512,
+		NumChannels: 2,
+	}
+	chain := vst2.NewChain(plugins, 512, 44100, 2)
+	assert.Equal(t, plugins[0].InitialDelay()+plugins[1].InitialDelay(), chain.InitialDelay())
+	sink := &mock.Sink{UID: phono.NewUID()}
+	playback, err := pipe.New(
+		44100,
+		pipe.WithPump(pump),
+		pipe.WithProcessors(chain),
+		pipe.WithSinks(sink),
+	)
+	assert.Nil(t, err)
+	err = pipe.Wait(playback.Run())
+	assert.Nil(t, err)
+	_, samples := sink.Count()
+	assert.Equal(t, int64(5*512), samples)
+	_ = pipe.Wait(playback.Close())
+}
+
+func TestBatch(t *testing.T) {
+	plugin, err := vst2.Open(test.Vst)
+	assert.Nil(t, err)
+	defer plugin.Close()
+
+	in, err := ioutil.TempDir("", "phono")
+	assert.Nil(t, err)
+	defer os.RemoveAll(in)
+	out := filepath.Join(in, "out")
+	data, err := ioutil.ReadFile(test.Data.Wav1)
+	assert.Nil(t, err)
+	for _, name := range []string{"1.wav", "2.wav", "3.WAV"} {
+		assert.Nil(t, ioutil.WriteFile(filepath.Join(in, name), data, 0644))
+	}
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(in, "broken.wav"), []byte("not a wav"), 0644))
+	assert.Nil(t, ioutil.WriteFile(filepath.Join(in, "readme.txt"), nil, 0644))
+
+	batch := vst2.NewBatch(plugin, 512)
+	batch.SetConcurrency(2)
+	err = batch.Render(in, out)
+	errs, ok := err.(vst2.BatchErrors)
+	assert.True(t, ok)
+	assert.Equal(t, 1, len(errs))
+	assert.NotNil(t, errs[filepath.Join(in, "broken.wav")])
+
+	for _, name := range []string{"1.wav", "2.wav", "3.WAV"} {
+		pump, err := wav.NewPump(filepath.Join(out, name), 512)
+		assert.Nil(t, err)
+		assert.Equal(t, test.Data.Wav1Samples, pump.WavSamples())
+		assert.Nil(t, pump.Flush(""))
+	}
+	_, err = os.Stat(filepath.Join(out, "readme.txt"))
+	assert.True(t, os.IsNotExist(err))
+
+	assert.NotNil(t, batch.Render(in, in))
+}
+
+func TestProcessorPool(t *testing.T) {
+	lib, err := vst2.OpenLibrary(test.Vst)
+	assert.Nil(t, err)
+	defer lib.Close()
+	_, err = vst2.NewProcessorPool(lib, 0)
+	assert.NotNil(t, err)
+	pool, err := vst2.NewProcessorPool(lib, 2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, pool.Size())
+
+	first, err := pool.Get()
+	assert.Nil(t, err)
+	initial := first.GetParameter(0)
+	first.SetParameter(0, initial+0.5)
+	second, err := pool.Get()
+	assert.Nil(t, err)
+	assert.NotEqual(t, first, second)
+
+	// all instances are taken, so Get blocks until one is returned.
+	got := make(chan *vst2.Plugin)
+	go func() {
+		plugin, _ := pool.Get()
+		got <- plugin
+	}()
+	select {
+	case <-got:
+		t.Fatal("Plugin is received from empty pool")
+	case <-time.After(50 * time.Millisecond):
+	}
+	pool.Put(first)
+	third := <-got
+	assert.Equal(t, first, third)
+	assert.Equal(t, initial, third.GetParameter(0))
+
+	// plugin returned twice is put to pool once.
+	pool.Put(second)
+	pool.Put(second)
+	fourth, err := pool.Get()
+	assert.Nil(t, err)
+	assert.Equal(t, second, fourth)
+
+	// Get which waits for plugin is released by Close.
+	errc := make(chan error)
+	go func() {
+		_, err := pool.Get()
+		errc <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	assert.Nil(t, pool.Close())
+	assert.Equal(t, vst2.ErrPoolClosed, <-errc)
+	_, err = pool.Get()
+	assert.Equal(t, vst2.ErrPoolClosed, err)
+
+	// plugins taken during close are closed when returned.
+	pool.Put(third)
+	pool.Put(fourth)
+	assert.Nil(t, third.Close())
+	assert.Nil(t, fourth.Close())
+}
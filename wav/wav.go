@@ -3,6 +3,8 @@ package wav
 import (
 	"errors"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"sync"
 
@@ -26,6 +28,11 @@ type (
 		file           *os.File
 		decoder        *wav.Decoder
 		ib             *audio.IntBuffer
+		data           []int // buffer for decoded samples.
+		read           int64 // number of samples read.
+		dataStart      int64 // offset of PCM data in file.
+		// m guards decoder, so SetPosition can be called while pump is running.
+		m sync.Mutex
 		// Once for single-use.
 		once sync.Once
 	}
@@ -50,6 +57,16 @@ var (
 	ErrSampleRateNotDefined = errors.New("Sample rate is not defined")
 	// ErrNumChannelsNotDefined is used when number of channels is not defined.
 	ErrNumChannelsNotDefined = errors.New("Number of channels is not defined")
+	// ErrTruncated is used when wav file has less data than its header declares.
+	ErrTruncated = errors.New("Wav file is truncated")
+)
+
+// Wav audio formats.
+const (
+	// FormatPCM is an integer PCM format.
+	FormatPCM = 1
+	// FormatFloat is an IEEE float format.
+	FormatFloat = 3
 )
 
 // NewPump creates a new wav pump and sets wav props.
@@ -64,7 +81,22 @@ func NewPump(path string, bufferSize phono.BufferSize) (*Pump, error) {
 		file.Close()
 		return nil, errors.New("Wav is not valid")
 	}
+	if err := validateFormat(int(decoder.BitDepth), int(decoder.WavAudioFormat)); err != nil {
+		file.Close()
+		return nil, err
+	}
+	// length of data is known when decoder reaches PCM chunk.
+	if err := decoder.FwdToPCM(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	dataStart, err := file.Seek(0, io.SeekCurrent)
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
 
+	data := make([]int, int(bufferSize)*decoder.Format().NumChannels)
 	return &Pump{
 		UID:            phono.NewUID(),
 		file:           file,
@@ -75,14 +107,28 @@ func NewPump(path string, bufferSize phono.BufferSize) (*Pump, error) {
 		wavBitDepth:    int(decoder.BitDepth),
 		wavAudioFormat: int(decoder.WavAudioFormat),
 		wavFormat:      decoder.Format(),
+		data:           data,
+		dataStart:      dataStart,
 		ib: &audio.IntBuffer{
 			Format:         decoder.Format(),
-			Data:           make([]int, int(bufferSize)*decoder.Format().NumChannels),
+			Data:           data,
 			SourceBitDepth: int(decoder.BitDepth),
 		},
 	}, nil
 }
 
+// validateFormat checks if samples of format can be converted.
+func validateFormat(bitDepth, audioFormat int) error {
+	switch {
+	case audioFormat == FormatFloat && bitDepth == 32:
+		return nil
+	case audioFormat == FormatPCM && (bitDepth == 8 || bitDepth == 16 || bitDepth == 24 || bitDepth == 32):
+		return nil
+	default:
+		return fmt.Errorf("Wav with audio format %v and bit depth %v is not supported", audioFormat, bitDepth)
+	}
+}
+
 // Flush closes the file.
 func (p *Pump) Flush(string) error {
 	return p.file.Close()
@@ -100,25 +146,52 @@ func (p *Pump) Pump(string) (phono.PumpFunc, error) {
 			return nil, errors.New("Source is not defined")
 		}
 
+		p.m.Lock()
+		defer p.m.Unlock()
+		p.ib.Data = p.data
 		readSamples, err := p.decoder.PCMBuffer(p.ib)
 		if err != nil {
 			return nil, err
 		}
 
 		if readSamples == 0 {
+			// header declares more samples than file contains.
+			if p.read*int64(p.wavBitDepth/8) < p.decoder.PCMLen() {
+				return nil, ErrTruncated
+			}
 			return nil, phono.ErrEOP
 		}
+		if readSamples%int(p.wavNumChannels) != 0 {
+			return nil, ErrTruncated
+		}
+		p.read += int64(readSamples)
 		// prune buffer to actual size
 		p.ib.Data = p.ib.Data[:readSamples]
 		// convert buffer to buffer
-		b, err := AsSamples(p.ib)
-		if err != nil {
-			return nil, err
-		}
-		return b, nil
+		return asSamples(p.ib.Data, p.wavNumChannels, p.wavBitDepth, p.wavAudioFormat), nil
 	}, nil
 }
 
+// SetPosition moves pump to position in samples per channel, so the next buffer
+// starts there. Position must be within samples declared in wav's header.
+// It's safe to call SetPosition while pump is running.
+func (p *Pump) SetPosition(position int64) error {
+	if position < 0 || position > p.WavSamples() {
+		return fmt.Errorf("Position %v is out of range of %v samples", position, p.WavSamples())
+	}
+	p.m.Lock()
+	defer p.m.Unlock()
+	samples := position * int64(p.wavNumChannels)
+	offset := samples * int64(p.wavBitDepth/8)
+	if _, err := p.file.Seek(p.dataStart+offset, io.SeekStart); err != nil {
+		return fmt.Errorf("Failed to seek wav: %v", err)
+	}
+	// decoder limits reads of PCM chunk by its length.
+	p.decoder.PCMChunk.R = io.LimitReader(p.file, p.decoder.PCMLen()-offset)
+	p.read = samples
+	return nil
+}
+
 // WavSampleRate returns wav's sample rate.
 func (p *Pump) WavSampleRate() phono.SampleRate {
 	return p.wavSampleRate
@@ -139,8 +212,19 @@ func (p *Pump) WavAudioFormat() int {
 	return p.wavAudioFormat
 }
 
-// NewSink creates new wav sink.
+// WavSamples returns number of samples per channel declared in wav's header.
+func (p *Pump) WavSamples() int64 {
+	return p.decoder.PCMLen() / int64(p.wavBitDepth/8) / int64(p.wavNumChannels)
+}
+
+// NewSink creates new wav sink. Supported formats are 8, 16, 24 and 32-bit
+// integer PCM and 32-bit float. If number of channels is zero, it's taken
+// from the first buffer, so sink follows processors which change number
+// of channels, e.g. mono synth with stereo output.
 func NewSink(path string, wavSampleRate phono.SampleRate, wavNumChannels phono.NumChannels, bitDepth int, wavAudioFormat int) (*Sink, error) {
+	if err := validateFormat(bitDepth, wavAudioFormat); err != nil {
+		return nil, err
+	}
 	f, err := os.Create(path)
 	if err != nil {
 		return nil, err
@@ -167,6 +251,17 @@ func NewSink(path string, wavSampleRate phono.SampleRate, wavNumChannels phono.N
 
 // Flush flushes encoder.
 func (s *Sink) Flush(string) error {
+	return s.close()
+}
+
+// Interrupt implements pipe.Interrupter. Header is finalized,
+// so the file is valid even if the stream ended early.
+func (s *Sink) Interrupt(string) error {
+	return s.close()
+}
+
+// close finalizes header and closes the file.
+func (s *Sink) close() error {
 	err := s.encoder.Close()
 	if err != nil {
 		return err
@@ -177,10 +272,16 @@ func (s *Sink) Flush(string) error {
 // Sink returns new Sink function instance.
 func (s *Sink) Sink(string) (phono.SinkFunc, error) {
 	return func(b phono.Buffer) error {
-		err := AsBuffer(b, s.ib)
-		if err != nil {
-			return err
+		// header is written with the first buffer, so it can be updated until then.
+		if s.wavNumChannels == 0 {
+			s.wavNumChannels = b.NumChannels()
+			s.encoder.NumChans = int(s.wavNumChannels)
+			s.ib.Format.NumChannels = int(s.wavNumChannels)
+		}
+		if nc := b.NumChannels(); nc != s.wavNumChannels {
+			return fmt.Errorf("Wav sink expects %v channels, but received buffer has %v", s.wavNumChannels, nc)
 		}
+		s.ib.Data = asInts(b, s.wavBitDepth, s.wavAudioFormat)
 		return s.encoder.Write(s.ib)
 	}, nil
 }
@@ -196,18 +297,46 @@ func AsSamples(ab audio.Buffer) (phono.Buffer, error) {
 	}
 
 	numChannels := ab.PCMFormat().NumChannels
-	b := phono.EmptyBuffer(phono.NumChannels(numChannels), phono.BufferSize(ab.NumFrames()))
-
 	switch ab.(type) {
 	case *audio.IntBuffer:
 		ib := ab.(*audio.IntBuffer)
-		b.ReadInts(ib.Data)
-		return b, nil
+		bitDepth := ib.SourceBitDepth
+		if bitDepth == 0 {
+			bitDepth = 16
+		}
+		return asSamples(ib.Data, phono.NumChannels(numChannels), bitDepth, FormatPCM), nil
 	default:
 		return nil, fmt.Errorf("Conversion to [][]float64 from %T is not defined", ab)
 	}
 }
 
+// asSamples converts interleaved samples of wav format into buffer.
+func asSamples(data []int, numChannels phono.NumChannels, bitDepth, audioFormat int) phono.Buffer {
+	nc := int(numChannels)
+	b := phono.EmptyBuffer(numChannels, phono.BufferSize(len(data)/nc))
+	// float samples are decoded as raw bits.
+	if audioFormat == FormatFloat {
+		for i := range b {
+			for j := range b[i] {
+				b[i][j] = float64(math.Float32frombits(uint32(data[j*nc+i])))
+			}
+		}
+		return b
+	}
+	scale := float64(int(1) << uint(bitDepth-1))
+	for i := range b {
+		for j := range b[i] {
+			v := data[j*nc+i]
+			// 8-bit samples are unsigned.
+			if bitDepth == 8 {
+				v -= 0x80
+			}
+			b[i][j] = float64(v) / scale
+		}
+	}
+	return b
+}
+
 // AsBuffer converts from [][]float64 to audio.Buffer.
 func AsBuffer(b phono.Buffer, ab audio.Buffer) error {
 	if ab == nil || b == nil {
@@ -217,9 +346,41 @@ func AsBuffer(b phono.Buffer, ab audio.Buffer) error {
 	switch ab.(type) {
 	case *audio.IntBuffer:
 		ib := ab.(*audio.IntBuffer)
-		ib.Data = b.Ints()
+		bitDepth := ib.SourceBitDepth
+		if bitDepth == 0 {
+			bitDepth = 16
+		}
+		ib.Data = asInts(b, bitDepth, FormatPCM)
 		return nil
 	default:
 		return fmt.Errorf("Conversion to %T from [][]float64 is not defined", ab)
 	}
 }
+
+// asInts converts buffer into interleaved samples of wav format.
+// Integer samples are clipped to [-1, 1] range and rounded to the nearest value.
+func asInts(b phono.Buffer, bitDepth, audioFormat int) []int {
+	nc := int(b.NumChannels())
+	ints := make([]int, int(b.Size())*nc)
+	// float samples are encoded as raw bits.
+	if audioFormat == FormatFloat {
+		for i := range b {
+			for j, v := range b[i] {
+				ints[j*nc+i] = int(int32(math.Float32bits(float32(v))))
+			}
+		}
+		return ints
+	}
+	scale := float64(int(1)<<uint(bitDepth-1) - 1)
+	for i := range b {
+		for j, v := range b[i] {
+			v = math.Max(-1, math.Min(1, v))
+			ints[j*nc+i] = int(math.Round(v * scale))
+			// 8-bit samples are unsigned.
+			if bitDepth == 8 {
+				ints[j*nc+i] += 0x80
+			}
+		}
+	}
+	return ints
+}
@@ -2,9 +2,11 @@ package wav_test
 
 import (
 	"fmt"
+	"io/ioutil"
 	"math"
 	"testing"
 
+	"github.com/dudk/phono/channel"
 	"github.com/dudk/phono/mock"
 	"github.com/dudk/phono/test"
 
@@ -70,6 +72,108 @@ func TestWavPipe(t *testing.T) {
 	}
 }
 
+func TestWavFormats(t *testing.T) {
+	formats := []struct {
+		bitDepth    int
+		audioFormat int
+	}{
+		{bitDepth: 8, audioFormat: wav.FormatPCM},
+		{bitDepth: 16, audioFormat: wav.FormatPCM},
+		{bitDepth: 24, audioFormat: wav.FormatPCM},
+		{bitDepth: 32, audioFormat: wav.FormatPCM},
+		{bitDepth: 32, audioFormat: wav.FormatFloat},
+	}
+	for _, format := range formats {
+		pump, err := wav.NewPump(test.Data.Wav1, bufferSize)
+		assert.Nil(t, err)
+		sink, err := wav.NewSink(test.Out.Format, pump.WavSampleRate(), pump.WavNumChannels(), format.bitDepth, format.audioFormat)
+		assert.Nil(t, err)
+		p, err := pipe.New(pump.WavSampleRate(), pipe.WithPump(pump), pipe.WithSinks(sink))
+		assert.Nil(t, err)
+		err = pipe.Wait(p.Run())
+		assert.Nil(t, err)
+		_ = pipe.Wait(p.Close())
+
+		pump, err = wav.NewPump(test.Out.Format, bufferSize)
+		assert.Nil(t, err)
+		assert.Equal(t, format.bitDepth, pump.WavBitDepth())
+		assert.Equal(t, format.audioFormat, pump.WavAudioFormat())
+		assert.Equal(t, test.Data.Wav1Samples, pump.WavSamples())
+		counter := &mock.Sink{UID: phono.NewUID()}
+		p, err = pipe.New(pump.WavSampleRate(), pipe.WithPump(pump), pipe.WithSinks(counter))
+		assert.Nil(t, err)
+		err = pipe.Wait(p.Run())
+		assert.Nil(t, err)
+		_, samples := counter.Count()
+		assert.Equal(t, test.Data.Wav1Samples, samples)
+		_ = pipe.Wait(p.Close())
+	}
+
+	_, err := wav.NewSink(test.Out.Format, 44100, 2, 12, wav.FormatPCM)
+	assert.NotNil(t, err)
+}
+
+func TestTruncatedWav(t *testing.T) {
+	data, err := ioutil.ReadFile(test.Data.Wav1)
+	assert.Nil(t, err)
+	err = ioutil.WriteFile(test.Out.Truncated, data[:len(data)/2], 0644)
+	assert.Nil(t, err)
+
+	pump, err := wav.NewPump(test.Out.Truncated, bufferSize)
+	assert.Nil(t, err)
+	p, err := pipe.New(
+		pump.WavSampleRate(),
+		pipe.WithPump(pump),
+		pipe.WithSinks(&mock.Sink{UID: phono.NewUID()}),
+	)
+	assert.Nil(t, err)
+	err = pipe.Wait(p.Run())
+	assert.Equal(t, wav.ErrTruncated, err)
+	_ = pipe.Wait(p.Close())
+}
+
+func TestPumpSeek(t *testing.T) {
+	// reads all buffers of pump.
+	readAll := func(pump *wav.Pump) phono.Buffer {
+		fn, err := pump.Pump("")
+		assert.Nil(t, err)
+		var result phono.Buffer
+		for {
+			b, err := fn()
+			if err != nil {
+				assert.Equal(t, phono.ErrEOP, err)
+				return result
+			}
+			result = result.Append(b)
+		}
+	}
+	pump, err := wav.NewPump(test.Data.Wav1, bufferSize)
+	assert.Nil(t, err)
+	full := readAll(pump)
+	assert.Nil(t, pump.Flush(""))
+
+	pump, err = wav.NewPump(test.Data.Wav1, bufferSize)
+	assert.Nil(t, err)
+	assert.NotNil(t, pump.SetPosition(-1))
+	assert.NotNil(t, pump.SetPosition(test.Data.Wav1Samples+1))
+	position := int64(1001)
+	assert.Nil(t, pump.SetPosition(position))
+	moved := readAll(pump)
+	assert.Equal(t, full.Size()-phono.BufferSize(position), moved.Size())
+	for i := range moved {
+		for j := range moved[i] {
+			if full[i][j+int(position)] != moved[i][j] {
+				t.Fatalf("Channel %v sample %v: expected %v got %v", i, j, full[i][j+int(position)], moved[i][j])
+			}
+		}
+	}
+
+	// seek to the end.
+	assert.Nil(t, pump.SetPosition(test.Data.Wav1Samples))
+	assert.Nil(t, readAll(pump))
+	assert.Nil(t, pump.Flush(""))
+}
+
 func TestIntBufferToSamples(t *testing.T) {
 	buf := &audio.IntBuffer{
 		Format: &audio.Format{
@@ -86,6 +190,13 @@ func TestIntBufferToSamples(t *testing.T) {
 		assert.Equal(t, float64(1)/0x8000, v)
 	}
 
+	buf.SourceBitDepth = 24
+	samples, err = wav.AsSamples(buf)
+	assert.Nil(t, err)
+	for _, v := range (samples)[1] {
+		assert.Equal(t, float64(2)/0x800000, v)
+	}
+
 	_, err = wav.AsSamples(nil)
 	assert.Nil(t, err)
 	buf.Format = nil
@@ -117,3 +228,30 @@ func TestSampelsToIntBuffer(t *testing.T) {
 	err = wav.AsBuffer(nil, nil)
 	assert.Nil(t, err)
 }
+
+func TestSinkNumChannels(t *testing.T) {
+	// mono input is converted to stereo before sink.
+	pump := &mock.Pump{
+		UID:         phono.NewUID(),
+		Limit:       10,
+		BufferSize:  bufferSize,
+		NumChannels: 1,
+		Value:       0.5,
+	}
+	sink, err := wav.NewSink(test.Out.Format, 44100, 0, 16, wav.FormatPCM)
+	assert.Nil(t, err)
+	p, err := pipe.New(
+		44100,
+		pipe.WithPump(pump),
+		pipe.WithProcessors(channel.NewToStereo()),
+		pipe.WithSinks(sink),
+	)
+	assert.Nil(t, err)
+	assert.Nil(t, pipe.Wait(p.Run()))
+	_ = pipe.Wait(p.Close())
+
+	result, err := wav.NewPump(test.Out.Format, bufferSize)
+	assert.Nil(t, err)
+	assert.Equal(t, phono.NumChannels(2), result.WavNumChannels())
+	assert.Equal(t, int64(10*bufferSize), result.WavSamples())
+}
@@ -0,0 +1,76 @@
+// Package width provides processor which changes width of stereo image.
+package width
+
+import (
+	"sync"
+
+	"github.com/dudk/phono"
+)
+
+// Width converts left and right channels into mid and side, scales side
+// by width factor and converts the result back. Width of 0 makes signal
+// mono, 1 keeps it unchanged and values above 1 make it wider. Buffers are
+// processed in place, only stereo ones are changed. When width is changed,
+// it's ramped over the next buffer to avoid clicks.
+type Width struct {
+	phono.UID
+	current float64 // width applied to the end of the last buffer.
+
+	m      sync.Mutex
+	target float64
+}
+
+// New creates new width processor.
+func New(width float64) *Width {
+	w := &Width{
+		UID: phono.NewUID(),
+	}
+	w.SetWidth(width)
+	w.current = w.target
+	return w
+}
+
+// SetWidth sets width factor. Negative values are treated as 0. It's safe
+// to call while processing.
+func (w *Width) SetWidth(width float64) {
+	if width < 0 {
+		width = 0
+	}
+	w.m.Lock()
+	w.target = width
+	w.m.Unlock()
+}
+
+// Process implements phono.Processor.
+func (w *Width) Process(string) (phono.ProcessFunc, error) {
+	return func(b phono.Buffer) (phono.Buffer, error) {
+		w.m.Lock()
+		target := w.target
+		w.m.Unlock()
+
+		if b.NumChannels() != 2 {
+			return b, nil
+		}
+		size := int(b.Size())
+		step := 0.0
+		if size > 0 {
+			step = (target - w.current) / float64(size)
+		}
+		left, right := b[0], b[1]
+		for j := range left {
+			mid := (left[j] + right[j]) / 2
+			side := (left[j] - right[j]) / 2 * (w.current + step*float64(j+1))
+			left[j], right[j] = mid+side, mid-side
+		}
+		w.current = target
+		return b, nil
+	}, nil
+}
+
+// Reset implements pipe.Resetter. Width isn't ramped at the start of run.
+func (w *Width) Reset(string) error {
+	w.m.Lock()
+	w.current = w.target
+	w.m.Unlock()
+	return nil
+}
@@ -0,0 +1,58 @@
+package width_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dudk/phono"
+	"github.com/dudk/phono/width"
+)
+
+func TestWidth(t *testing.T) {
+	tests := []struct {
+		width    float64
+		expected phono.Buffer
+	}{
+		{width: 1, expected: phono.Buffer{[]float64{1, 0.5}, []float64{0, 0.5}}},
+		{width: 0, expected: phono.Buffer{[]float64{0.5, 0.5}, []float64{0.5, 0.5}}},
+		{width: 2, expected: phono.Buffer{[]float64{1.5, 0.5}, []float64{-0.5, 0.5}}},
+		{width: -1, expected: phono.Buffer{[]float64{0.5, 0.5}, []float64{0.5, 0.5}}},
+	}
+	for _, test := range tests {
+		fn, err := width.New(test.width).Process("")
+		assert.Nil(t, err)
+		result, err := fn(phono.Buffer{[]float64{1, 0.5}, []float64{0, 0.5}})
+		assert.Nil(t, err)
+		for i := range test.expected {
+			assert.InDeltaSlice(t, test.expected[i], result[i], 1e-9, "width %v", test.width)
+		}
+	}
+
+	// mono input isn't changed.
+	fn, err := width.New(0).Process("")
+	assert.Nil(t, err)
+	result, err := fn(phono.Buffer{[]float64{1, 0}})
+	assert.Nil(t, err)
+	assert.Equal(t, phono.Buffer{[]float64{1, 0}}, result)
+}
+
+func TestWidthRamp(t *testing.T) {
+	w := width.New(1)
+	fn, err := w.Process("")
+	assert.Nil(t, err)
+
+	// side is ramped to the new width.
+	w.SetWidth(0)
+	result, err := fn(phono.Buffer{[]float64{1, 1, 1, 1}, []float64{-1, -1, -1, -1}})
+	assert.Nil(t, err)
+	assert.InDeltaSlice(t, []float64{0.75, 0.5, 0.25, 0}, result[0], 1e-9)
+	assert.InDeltaSlice(t, []float64{-0.75, -0.5, -0.25, 0}, result[1], 1e-9)
+
+	// width isn't ramped after reset.
+	w.SetWidth(1)
+	assert.Nil(t, w.Reset(""))
+	result, err = fn(phono.Buffer{[]float64{1, 1}, []float64{-1, -1}})
+	assert.Nil(t, err)
+	assert.Equal(t, phono.Buffer{[]float64{1, 1}, []float64{-1, -1}}, result)
+}